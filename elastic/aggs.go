@@ -0,0 +1,102 @@
+package elastic
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var allowCalendarInterval = []string{"minute", "1m", "hour", "1h", "day", "1d", "week", "1w", "month", "1M", "quarter", "1q", "year", "1y"}
+var fixedIntervalPattern = regexp.MustCompile(`^\d+(ms|s|m|h|d)$`)
+
+// WithDateHistogram adds a date_histogram aggregation called name over field.
+// interval is either a calendar interval (1d, month, ...) or a fixed one (90m, 12h, ...).
+func (e *Elastic) WithDateHistogram(name, field, interval string) *Elastic {
+	if name == "" || field == "" {
+		e.setOptionErr(ErrInvalidAggregation)
+		return e
+	}
+
+	histogram := map[string]interface{}{
+		"field": field,
+	}
+	switch {
+	case contains(allowCalendarInterval, interval):
+		histogram["calendar_interval"] = interval
+	case fixedIntervalPattern.MatchString(interval):
+		histogram["fixed_interval"] = interval
+	default:
+		e.setOptionErr(ErrInvalidInterval)
+		return e
+	}
+
+	if e.Aggs == nil {
+		e.Aggs = make(map[string]interface{})
+	}
+	e.Aggs[name] = map[string]interface{}{
+		"date_histogram": histogram,
+	}
+	return e
+}
+
+// Agg is a named aggregation that can be nested under a bucket aggregation or
+// added to the search with WithAgg.
+type Agg struct {
+	Name string
+	Body map[string]interface{}
+	err  error
+}
+
+// TermsAggWithSub returns a terms aggregation over field with the sub
+// aggregations nested under its buckets. Names must be unique within a level.
+func TermsAggWithSub(name, field string, size int, sub ...Agg) Agg {
+	a := Agg{Name: name}
+	if name == "" || field == "" || size <= 0 {
+		a.err = ErrInvalidAggregation
+		return a
+	}
+
+	a.Body = map[string]interface{}{
+		"terms": map[string]interface{}{
+			"field": field,
+			"size":  size,
+		},
+	}
+	if len(sub) > 0 {
+		aggs := make(map[string]interface{}, len(sub))
+		for _, s := range sub {
+			if s.err != nil {
+				a.err = s.err
+				return a
+			}
+			if _, ok := aggs[s.Name]; ok {
+				a.err = fmt.Errorf("%w: %q", ErrDuplicateAggName, s.Name)
+				return a
+			}
+			aggs[s.Name] = s.Body
+		}
+		a.Body["aggs"] = aggs
+	}
+	return a
+}
+
+// WithAgg adds a to the top-level aggregations.
+func (e *Elastic) WithAgg(a Agg) *Elastic {
+	if a.err != nil {
+		e.setOptionErr(a.err)
+		return e
+	}
+	if a.Name == "" || a.Body == nil {
+		e.setOptionErr(ErrInvalidAggregation)
+		return e
+	}
+	if _, ok := e.Aggs[a.Name]; ok {
+		e.setOptionErr(fmt.Errorf("%w: %q", ErrDuplicateAggName, a.Name))
+		return e
+	}
+
+	if e.Aggs == nil {
+		e.Aggs = make(map[string]interface{})
+	}
+	e.Aggs[a.Name] = a.Body
+	return e
+}
@@ -0,0 +1,31 @@
+package elastic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// CacheKey returns a stable hash of the search request, suitable for caching
+// results: the index, the body and the request parameters that change which
+// hits come back, such as routing and preference. Clauses are sorted and
+// object keys serialized in order, so queries that differ only in condition
+// order share a key.
+func (e *Elastic) CacheKey() (string, error) {
+	c := e.Clone()
+	c.stableOrder = true
+	c.format = FormatSorted
+	c.profiler = nil
+	c.trace = nil
+	rs, err := c.ParseToSearchRequest()
+	if err != nil {
+		return "", err
+	}
+	// The request cache only affects where results come from.
+	rs.Params.Del("request_cache")
+	b, err := c.marshal(rs)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
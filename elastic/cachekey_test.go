@@ -0,0 +1,63 @@
+package elastic
+
+import "testing"
+
+func cacheKey(t *testing.T, e *Elastic) string {
+	t.Helper()
+	key, err := e.CacheKey()
+	if err != nil {
+		t.Fatalf("CacheKey: %v", err)
+	}
+	return key
+}
+
+func TestCacheKey(t *testing.T) {
+	a := cond("keyword", "eq", "and", "a", "x")
+	b := cond("number", "gte", "and", "n", 10)
+	c := cond("keyword", "eq", "or", "c", "z")
+	d := cond("keyword", "eq", "or", "d", "w")
+	base := cacheKey(t, New([]Condition{a, b, c, d}))
+	requestCache := New([]Condition{a, b, c, d})
+	requestCache.RequestCache = new(bool)
+
+	tests := []struct {
+		name string
+		e    *Elastic
+		same bool
+	}{
+		{"reordered", New([]Condition{d, b, c, a}), true},
+		{"reordered and", New([]Condition{b, a, c, d}), true},
+		{"size", New([]Condition{a, b, c, d}).WithSize(10), false},
+		{"different value", New([]Condition{a, cond("number", "gte", "and", "n", 11), c, d}), false},
+		{"or moved to and", New([]Condition{a, b, cond("keyword", "eq", "and", "c", "z"), d}), false},
+		{"missing condition", New([]Condition{a, b, c}), false},
+		{"routing", New([]Condition{a, b, c, d}).WithRouting("r1"), false},
+		{"preference", New([]Condition{a, b, c, d}).WithPreference("s1"), false},
+		{"request cache", requestCache, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheKey(t, tt.e); (got == base) != tt.same {
+				t.Errorf("same key = %v, want %v", got == base, tt.same)
+			}
+		})
+	}
+}
+
+func TestCacheKeyLeavesBuildOrder(t *testing.T) {
+	e := New([]Condition{cond("keyword", "eq", "and", "b", "y"), cond("keyword", "eq", "and", "a", "x")})
+	before := queryJSON(t, e)
+	cacheKey(t, e)
+	if got := queryJSON(t, e); got != before {
+		t.Errorf("CacheKey changed the build: got %s, want %s", got, before)
+	}
+}
+
+func TestCacheKeyIndex(t *testing.T) {
+	e := New([]Condition{cond("keyword", "eq", "and", "a", "x")})
+	a, b := e.Clone(), e.Clone()
+	a.Index, b.Index = "a", "b"
+	if cacheKey(t, a) == cacheKey(t, b) {
+		t.Error("indexes a and b share a key")
+	}
+}
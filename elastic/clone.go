@@ -0,0 +1,91 @@
+package elastic
+
+// Clone returns a copy of e that can be modified and built independently.
+func (e *Elastic) Clone() *Elastic {
+	c := *e
+	c.Query = Query{}
+	c.Params = cloneConditions(e.Params)
+	c.Groups = cloneGroups(e.Groups)
+	c.NestedPaths = append([]string(nil), e.NestedPaths...)
+	c.raw = append([]rawClause(nil), e.raw...)
+	c.pinnedIDs = append([]string(nil), e.pinnedIDs...)
+	c.routing = append([]string(nil), e.routing...)
+	c.decays = append([]map[string]interface{}(nil), e.decays...)
+	if e.Highlight != nil {
+		h := *e.Highlight
+		h.Fields = append([]HighlightField(nil), e.Highlight.Fields...)
+		h.Query = cloneConditions(e.Highlight.Query)
+		c.Highlight = &h
+	}
+	if e.Aggs != nil {
+		c.Aggs = make(map[string]interface{}, len(e.Aggs))
+		for k, v := range e.Aggs {
+			c.Aggs[k] = v
+		}
+	}
+	c.Fields = append([]FieldSpec(nil), e.Fields...)
+	c.DocValueFields = append([]FieldSpec(nil), e.DocValueFields...)
+	c.StoredFields = append([]string(nil), e.StoredFields...)
+	c.Sort = append([]SortClause(nil), e.Sort...)
+	if e.RuntimeMappings != nil {
+		c.RuntimeMappings = make(map[string]interface{}, len(e.RuntimeMappings))
+		for k, v := range e.RuntimeMappings {
+			c.RuntimeMappings[k] = v
+		}
+	}
+	c.seen = nil
+	c.soft = nil
+	c.warnings = nil
+	return &c
+}
+
+// cloneEmpty returns a clone of e with its options and search settings but no
+// conditions, groups, raw clauses or query wrappers.
+func (e *Elastic) cloneEmpty() *Elastic {
+	c := e.Clone()
+	c.Params = nil
+	c.Groups = nil
+	c.raw = nil
+	c.pinnedIDs = nil
+	c.decays = nil
+	c.minimumShouldMatch = nil
+	return c
+}
+
+// WithCondition returns a clone of e with cond appended, leaving e unchanged.
+func (e *Elastic) WithCondition(cond Condition) *Elastic {
+	c := e.Clone()
+	c.Params = append(c.Params, cond)
+	return c
+}
+
+func cloneConditions(in []Condition) (rs []Condition) {
+	if in == nil {
+		return
+	}
+	rs = make([]Condition, len(in))
+	for i := 0; i < len(in); i++ {
+		rs[i] = in[i]
+		rs[i].Keys = append([]string(nil), in[i].Keys...)
+		if in[i].Meta != nil {
+			rs[i].Meta = make(map[string]interface{}, len(in[i].Meta))
+			for k, v := range in[i].Meta {
+				rs[i].Meta[k] = v
+			}
+		}
+	}
+	return
+}
+
+func cloneGroups(in []Group) (rs []Group) {
+	if in == nil {
+		return
+	}
+	rs = make([]Group, len(in))
+	for i := 0; i < len(in); i++ {
+		rs[i] = in[i]
+		rs[i].Conditions = cloneConditions(in[i].Conditions)
+		rs[i].Groups = cloneGroups(in[i].Groups)
+	}
+	return
+}
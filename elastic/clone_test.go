@@ -0,0 +1,104 @@
+package elastic
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithConditionLeavesOriginal(t *testing.T) {
+	base := New([]Condition{cond("keyword", "eq", "and", "a", "x")})
+	before := queryJSON(t, base)
+
+	derived := []*Elastic{
+		base.WithCondition(cond("keyword", "eq", "and", "b", "y")),
+		base.WithCondition(cond("keyword", "eq", "and", "c", "z")),
+	}
+	derived[0].Params[0].Value = "changed"
+
+	if got := queryJSON(t, base); got != before {
+		t.Errorf("base changed: got %s, want %s", got, before)
+	}
+	if len(base.Params) != 1 {
+		t.Errorf("base has %d params, want 1", len(base.Params))
+	}
+	want := []string{
+		`{"query":{"bool":{"must":[{"term":{"a":"changed"}},{"term":{"b":"y"}}]}}}`,
+		`{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"c":"z"}}]}}}`,
+	}
+	for i, e := range derived {
+		if got := queryJSON(t, e); got != want[i] {
+			t.Errorf("derived %d: got  %s\nwant %s", i, got, want[i])
+		}
+	}
+}
+
+func TestCloneCopiesSlices(t *testing.T) {
+	base := New([]Condition{{Type: "text", ComparisonOperators: "combined_fields", LogicalOperators: "and", Keys: []string{"a", "b"}, Value: "x"}})
+	base.Groups = []Group{{Conditions: []Condition{cond("keyword", "eq", "and", "g", "1")}}}
+	before := queryJSON(t, base)
+
+	c := base.Clone()
+	c.Params[0].Keys[0] = "changed"
+	c.Groups[0].Conditions[0].Value = "changed"
+
+	if got := queryJSON(t, base); got != before {
+		t.Errorf("base changed: got %s, want %s", got, before)
+	}
+}
+
+func TestMeta(t *testing.T) {
+	annotated := func() Condition {
+		c := cond("keyword", "eq", "and", "a", "x")
+		c.Meta = map[string]interface{}{"label": "added by filter X"}
+		return c
+	}
+	base := New([]Condition{annotated()})
+	base.Groups = []Group{{Conditions: []Condition{annotated()}}}
+	tests := []struct {
+		name string
+		e    *Elastic
+		meta []map[string]interface{} // Meta of each top-level condition
+	}{
+		{"built", base, []map[string]interface{}{{"label": "added by filter X"}}},
+		{"clone", base.Clone(), []map[string]interface{}{{"label": "added by filter X"}}},
+		{"merge", base.Merge(New([]Condition{cond("keyword", "eq", "and", "b", "y")})), []map[string]interface{}{{"label": "added by filter X"}, nil}},
+		{"merged into", New([]Condition{cond("keyword", "eq", "and", "b", "y")}).Merge(base), []map[string]interface{}{nil, {"label": "added by filter X"}}},
+		{"with condition", base.WithCondition(annotated()), []map[string]interface{}{{"label": "added by filter X"}, {"label": "added by filter X"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := tt.e.ParseToSearchBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := json.Marshal(body)
+			if strings.Contains(string(b), "label") || strings.Contains(string(b), "filter X") {
+				t.Errorf("Meta serialized into %s", b)
+			}
+			if len(tt.e.Params) != len(tt.meta) {
+				t.Fatalf("%d params, want %d", len(tt.e.Params), len(tt.meta))
+			}
+			for i, want := range tt.meta {
+				if got := tt.e.Params[i].Meta; !reflect.DeepEqual(got, want) {
+					t.Errorf("param %d Meta = %v, want %v", i, got, want)
+				}
+			}
+			if got := tt.e.Groups[0].Conditions[0].Meta; got["label"] != "added by filter X" {
+				t.Errorf("group condition Meta = %v", got)
+			}
+		})
+	}
+}
+
+func TestCloneCopiesMeta(t *testing.T) {
+	c := cond("keyword", "eq", "and", "a", "x")
+	c.Meta = map[string]interface{}{"label": "x"}
+	base := New([]Condition{c})
+	clone := base.Clone()
+	clone.Params[0].Meta["label"] = "changed"
+	if got := base.Params[0].Meta["label"]; got != "x" {
+		t.Errorf("base Meta changed to %v", got)
+	}
+}
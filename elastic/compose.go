@@ -0,0 +1,34 @@
+package elastic
+
+// Merge returns a clone of e with the conditions, groups and raw clauses of
+// other appended. Options and search settings are those of e.
+func (e *Elastic) Merge(other *Elastic) *Elastic {
+	c := e.Clone()
+	o := other.Clone()
+	c.Params = append(c.Params, o.Params...)
+	c.Groups = append(c.Groups, o.Groups...)
+	c.raw = append(c.raw, o.raw...)
+	return c
+}
+
+// Not returns a new query matching every document e does not match. The built
+// query of e, including any function_score or pinned wrapper, becomes the only
+// must_not clause; search settings such as size and highlight are kept. A knn
+// search has no query to negate and fails with ErrKNNNotQuery.
+func (e *Elastic) Not() (*Elastic, error) {
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rs["knn"]; ok {
+		return nil, ErrKNNNotQuery
+	}
+	query, _ := rs["query"].(map[string]interface{})
+
+	c := e.cloneEmpty()
+	err = c.AddRaw("must_not", query)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
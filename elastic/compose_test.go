@@ -0,0 +1,45 @@
+package elastic
+
+import "testing"
+
+func TestNot(t *testing.T) {
+	e := New([]Condition{
+		cond("keyword", "eq", "and", "a", "x"),
+		cond("keyword", "eq", "or", "b", "x"),
+		cond("keyword", "eq", "or", "c", "x"),
+		cond("keyword", "neq", "and", "d", "x"),
+	}).WithSize(5)
+	not, err := e.Not()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notNot, err := not.Not()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, negated, doubled := builtQuery(t, e), builtQuery(t, not), builtQuery(t, notNot)
+	for _, doc := range docs([]string{"a", "b", "c", "d"}, "x", "y") {
+		want := matches(t, query, doc)
+		if got := matches(t, negated, doc); got == want {
+			t.Errorf("doc %v: Not matches %v, want %v", doc, got, !want)
+		}
+		if got := matches(t, doubled, doc); got != want {
+			t.Errorf("doc %v: Not().Not() matches %v, want %v", doc, got, want)
+		}
+	}
+	if rs := searchBody(t, notNot); rs["size"] != 5 {
+		t.Errorf("size = %v, want 5", rs["size"])
+	}
+}
+
+func TestNotLeavesOriginal(t *testing.T) {
+	e := New([]Condition{cond("keyword", "eq", "and", "a", "x")})
+	before := queryJSON(t, e)
+	if _, err := e.Not(); err != nil {
+		t.Fatal(err)
+	}
+	if got := queryJSON(t, e); got != before {
+		t.Errorf("got %s, want %s", got, before)
+	}
+}
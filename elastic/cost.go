@@ -0,0 +1,63 @@
+package elastic
+
+// Cost counts the clauses of a query that are known to be slow.
+type Cost struct {
+	Wildcard int
+	Regexp   int
+	Script   int
+	Fuzzy    int // fuzzy queries and match clauses with fuzziness
+}
+
+// Total is the number of expensive clauses.
+func (c Cost) Total() int {
+	return c.Wildcard + c.Regexp + c.Script + c.Fuzzy
+}
+
+// Leaf queries whose bodies hold field names and options, not other queries.
+var leafQueries = []string{"term", "terms", "match", "match_phrase", "match_bool_prefix", "wildcard", "regexp", "fuzzy",
+	"prefix", "range", "intervals", "combined_fields", "more_like_this", "geo_distance", "geo_polygon", "rank_feature", "exists", "ids"}
+
+// EstimateCost builds the query, raw clauses and wrappers included, and counts
+// its expensive clauses so callers can reject queries over a budget. A knn
+// search fails with ErrKNNNotQuery.
+func (e *Elastic) EstimateCost() (c Cost, err error) {
+	rs, err := e.Clone().ParseToQuery()
+	if err != nil {
+		return
+	}
+	if _, ok := rs["knn"]; ok {
+		return c, ErrKNNNotQuery
+	}
+	c.walk(rs["query"], "", false)
+	return
+}
+
+func (c *Cost) walk(v interface{}, parent string, leaf bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if k == "fuzziness" {
+				c.Fuzzy++
+			}
+			if !leaf {
+				switch k {
+				case "wildcard":
+					c.Wildcard++
+				case "regexp":
+					c.Regexp++
+				case "fuzzy":
+					c.Fuzzy++
+				case "script":
+					if parent != "script" {
+						c.Script++
+					}
+				}
+			}
+			c.walk(child, k, leaf || contains(leafQueries, k))
+		}
+	case []interface{}:
+		for _, child := range v {
+			c.walk(child, parent, leaf)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package elastic
+
+var allowDecay = []string{"gauss", "linear", "exp"}
+
+// DecayParams are the parameters of a function_score decay function.
+type DecayParams struct {
+	Origin interface{} // e.g. "now", a number or a geo point
+	Scale  string      // required, e.g. "10d" or "2km"
+	Offset string
+	Decay  *float64
+}
+
+// WithDecay scores documents by how close field is to params.Origin using a
+// gauss, linear or exp decay function, wrapping the query in function_score.
+func (e *Elastic) WithDecay(funcType, field string, params DecayParams) *Elastic {
+	if !contains(allowDecay, funcType) || field == "" || params.Scale == "" {
+		e.setOptionErr(ErrInvalidDecay)
+		return e
+	}
+
+	body := map[string]interface{}{
+		"scale": params.Scale,
+	}
+	if params.Origin != nil {
+		body["origin"] = params.Origin
+	}
+	if params.Offset != "" {
+		body["offset"] = params.Offset
+	}
+	if params.Decay != nil {
+		body["decay"] = *params.Decay
+	}
+	e.decays = append(e.decays, map[string]interface{}{
+		funcType: map[string]interface{}{
+			field: body,
+		},
+	})
+	return e
+}
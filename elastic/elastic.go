@@ -1,31 +1,155 @@
 package elastic
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
-var allowType = []string{"text", "number", "array", "date"}
-var allowText = []string{"eq", "neq", "like", "nlike"}
-var allowNumber = []string{"eq", "neq", "lt", "lte", "gt", "gte"}
+var allowType = []string{"text", "keyword", "number", "array", "date", "range", "geo", "ip", "expr", "boolean", "vector"}
+var allowText = []string{"eq", "neq", "like", "nlike", "phrase", "nphrase", "intervals", "combined_fields", "more_like_this", "wildcard", "regexp", "match_bool_prefix", "nmatch_bool_prefix"}
+var allowKeyword = []string{"eq", "neq", "wildcard", "regexp", "starts_with", "nstarts_with", "ends_with", "nends_with", "contains", "ncontains"}
+var allowNumber = []string{"eq", "neq", "lt", "lte", "gt", "gte", "rank_feature"}
+var allowRankFeatureFunctions = []string{"saturation", "log", "sigmoid", "linear"}
 var allowArray = []string{"in", "nin"}
 var allowDate = []string{"lt", "lte", "gt", "gte"}
+var allowRange = []string{"lt", "lte", "gt", "gte"}
+var allowRelation = []string{"", "within", "contains", "intersects"}
+var allowGeo = []string{"geo_distance", "geo_polygon"}
+var allowBoolean = []string{"eq", "neq"}
+var allowExpr = []string{"expr"}
+var allowVector = []string{"knn"}
+var allowIP = []string{"eq", "neq", "lt", "lte", "gt", "gte"}
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+var distancePattern = regexp.MustCompile(`^\d+(\.\d+)?(mm|cm|m|km|in|ft|yd|mi|nmi)$`)
 var allowLogicalOperators = []string{"and", "or"}
-var allowMustNot = []string{"neq", "nlike", "nin"}
+var allowMustNot = []string{"neq", "nlike", "nin", "nphrase", "nstarts_with", "nends_with", "ncontains", "nmatch_bool_prefix"}
+var allowSection = []string{"must", "should", "must_not", "filter"}
+var allowTermOperators = []string{"", "and", "or"}
+var allowZeroTerms = []string{"", "none", "all"}
 
 type Condition struct {
-	Type                string // text, number, array, date
-	ComparisonOperators string // eq, neq, in, nin, like, nlike, lt, lte, gt, gte
-	LogicalOperators    string // and, or
+	Type                string // text, keyword, number, array, date, range, geo, ip, expr, boolean; auto or empty infers it from Value
+	ComparisonOperators string // eq, neq, in, nin, like, nlike, phrase, nphrase, match_bool_prefix, nmatch_bool_prefix, intervals, wildcard, regexp, starts_with, ends_with, contains (and n-prefixed negations), lt, lte, gt, gte, combined_fields, more_like_this, rank_feature, geo_distance, geo_polygon, knn (vector type, Value is a KNN), expr (Value is an expression string such as "active AND (age > 18 OR age < 5)")
+	LogicalOperators    string // and, or; empty takes the WithDefaultLogical operator, and by default
 	Key                 string
+	Keys                []string // fields for multi-field operators such as combined_fields, more_like_this
+	Operator            string   // and, or; how terms of a full-text query are combined
+	Slop                *int     // phrase, nphrase
+	MaxGaps             *int     // intervals; -1 means no limit
+	Ordered             bool     // intervals
+	Relation            string   // within, contains, intersects; range type only
+	Nested              string   // nested path the clause is wrapped in
+	Boost               *float64 // applies in any section, so OR alternatives can carry different weights; 0 adds nothing to the score
+	ConstantScore       *float64 // wrap the clause in constant_score with this boost, so a match adds a fixed score; not on negated operators
+	Fuzziness           string   // like, nlike; e.g. AUTO, 1, 2
+	PrefixLength        *int     // like, nlike
+	MaxExpansions       *int     // like, nlike
+	Distance            string   // geo_distance; number plus unit, e.g. 10km
+	Required            bool     // route an or condition to must; the remaining or conditions become optional
+	Soft                bool     // route the condition to should whatever its logical operator, so it only raises the score of matching documents and never filters
+	SkipIfEmpty         bool     // drop the condition when Value is empty instead of querying for an empty value
+	ZeroTerms           string   // none, all; what like, phrase and combined_fields match when analysis leaves no tokens
+	Analyzer            string   // like, phrase, match_bool_prefix; overrides WithSearchAnalyzer
+	AutoSynonyms        *bool    // like, nlike, combined_fields; auto_generate_synonyms_phrase_query, true by default in Elasticsearch
+	Lenient             *bool    // like, nlike; ignore format errors such as text queried on a number field
 	Value               interface{}
+	Meta                map[string]interface{} // caller annotations such as UI labels; never part of the DSL
+
+	denied bool // set by the field allowlist or denylist
 }
 
 type Elastic struct {
-	Query  Query       `json:"query"`
-	Params []Condition `json:"input"`
+	Query Query `json:"query"`
+	// Params and Groups are builder input and are not serialized; marshal them
+	// directly if the conditions themselves need to be stored.
+	Params []Condition `json:"-"`
+	Groups []Group     `json:"-"`
+
+	MinScore *float64 `json:"min_score,omitempty"`
+	Size     *int     `json:"size,omitempty"`
+	From     *int     `json:"from,omitempty"`
+
+	// Sort is applied in order; _score and _script sorts are supported.
+	Sort      []SortClause           `json:"-"`
+	Highlight *Highlight             `json:"-"`
+	Aggs      map[string]interface{} `json:"-"`
+
+	// Source returns all (true) or no (false) source; it cannot be combined
+	// with SourceIncludes or SourceExcludes.
+	Source         *bool    `json:"-"`
+	SourceIncludes []string `json:"-"`
+	SourceExcludes []string `json:"-"`
+	// SourceAsParams sends SourceIncludes and SourceExcludes as the
+	// _source_includes and _source_excludes URL parameters of
+	// ParseToSearchRequest instead of in the body.
+	SourceAsParams bool `json:"-"`
+	// Fields are retrieved through the fields API (Elasticsearch 7.11+).
+	Fields []FieldSpec `json:"-"`
+	// DocValueFields are returned from doc values, optionally formatted.
+	DocValueFields []FieldSpec `json:"-"`
+	// StoredFields lists stored fields to return; _none_ alone disables them.
+	StoredFields []string `json:"-"`
+	// RuntimeMappings defines runtime fields that conditions may query like
+	// mapped fields.
+	RuntimeMappings map[string]interface{} `json:"-"`
+	// NestedPaths lists the nested-mapped paths of the index; conditions on keys
+	// under them must set Condition.Nested.
+	NestedPaths []string `json:"-"`
+
+	Index        string `json:"-"`
+	SearchType   string `json:"-"` // query_then_fetch, dfs_query_then_fetch
+	RequestCache *bool  `json:"-"`
+
+	IgnoreUnavailable *bool `json:"-"`
+	AllowNoIndices    *bool `json:"-"`
+
+	deduplicate        bool
+	stableOrder        bool
+	keywordSuffix      string
+	allowedFields      map[string]bool
+	deniedFields       map[string]bool
+	fieldMap           map[string]string
+	explain            bool
+	trackScores        bool
+	profile            bool
+	chunkSize          int
+	maxIterTerms       int
+	maxClauses         int
+	strict             bool
+	strictFieldNames   bool
+	defaultLogical     string
+	analyzer           string
+	timeout            string
+	preference         string
+	routing            []string
+	minimumShouldMatch interface{}
+	conflictDetection  bool
+	groupByKey         bool
+	flatten            bool
+	format             Format
+	pinnedIDs          []string
+	autoNames          string
+	decays             []map[string]interface{}
+	profiler           func(stage string, d time.Duration)
+	queryCache         *queryCache
+	optionErr          error // first invalid argument passed to a With* option
+	warnings           []Warning
+	raw                []rawClause
+	seen               map[seenKey]int
+	soft               map[*BoolQuery][]interface{}
+	alternatives       map[*BoolQuery]bool // top-level must bools holding or alternatives
+	clauseCount        int
+	trace              *[]ClauseTrace
+	traceIndex         int
 }
 
 type Query struct {
@@ -38,8 +162,12 @@ type Bool struct {
 
 type BoolQuery struct {
 	Must    []interface{} `json:"must,omitempty"`
+	Filter  []interface{} `json:"filter,omitempty"`
 	MustNot []interface{} `json:"must_not,omitempty"`
 	Should  []interface{} `json:"should,omitempty"`
+
+	MinimumShouldMatch interface{} `json:"minimum_should_match,omitempty"`
+	Name               string      `json:"_name,omitempty"`
 }
 
 //func main() {
@@ -95,81 +223,893 @@ func New(in []Condition) *Elastic {
 	return &Elastic{Params: in}
 }
 
+// FromMap builds an Elastic with one text eq and condition per entry of m,
+// ordered by key so the output is deterministic.
+func FromMap(m map[string]string) *Elastic {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	in := make([]Condition, 0, len(keys))
+	for _, k := range keys {
+		in = append(in, Condition{
+			Type:                "text",
+			ComparisonOperators: "eq",
+			LogicalOperators:    "and",
+			Key:                 k,
+			Value:               m[k],
+		})
+	}
+	return New(in)
+}
+
 func (e *Elastic) ParseToQuery() (rs map[string]interface{}, err error) {
-	in := e.Params
-	err = validate(in)
-	in = toLower(in)
+	if e.queryCache == nil || e.trace != nil || e.optionErr != nil {
+		return e.parseToQuery()
+	}
+	key, ok := e.queryCacheKey()
+	if !ok {
+		return e.parseToQuery()
+	}
+	if rs, ok = e.cachedQuery(key); ok {
+		return rs, nil
+	}
+	rs, err = e.parseToQuery()
+	if err == nil {
+		e.cacheQuery(key, rs)
+	}
+	return
+}
+
+func (e *Elastic) parseToQuery() (rs map[string]interface{}, err error) {
+	in, groups, err := e.prepare()
 	if err != nil {
 		return
 	}
 
+	start := e.startStage()
+	e.Query = Query{}
+	e.seen = make(map[seenKey]int)
+	e.soft = make(map[*BoolQuery][]interface{})
+	e.alternatives = make(map[*BoolQuery]bool)
+	e.clauseCount = 0
+	var byKey map[string]*BoolQuery
+	var keys []string
+	var knn map[string]interface{}
+	if e.groupByKey {
+		byKey = make(map[string]*BoolQuery)
+	}
 	for i := 0; i < len(in); i++ {
 		cond := in[i]
-		err = e.parseToDSLQuery(cond)
+		if isKNN(cond) {
+			if !skipped(cond) {
+				if knn != nil {
+					return nil, ErrMultipleKNN
+				}
+				knn = e.knnToDSL(cond)
+				if e.trace != nil {
+					*e.trace = append(*e.trace, ClauseTrace{Index: i, Section: "knn", Operator: "knn", Key: knn["field"].(string)})
+				}
+			}
+			continue
+		}
+		e.traceIndex = i
+		b := &e.Query.Query.Bool
+		if byKey != nil && groupsByKey(cond) {
+			if byKey[cond.Key] == nil {
+				byKey[cond.Key] = &BoolQuery{}
+				keys = append(keys, cond.Key)
+			}
+			b = byKey[cond.Key]
+		}
+		err = e.parseToDSLQuery(b, cond)
 		if err != nil {
 			return
 		}
 	}
+	e.traceIndex = -1 // group conditions are not traced
+	if knn != nil {
+		if cond, ok := scoringCondition(in, groups); ok {
+			return nil, fmt.Errorf("%w: %s", ErrKNNScoringCondition, cond.Key)
+		}
+	}
+	for _, key := range keys {
+		e.attachKeyGroup(&e.Query.Query.Bool, byKey[key])
+	}
+	err = e.parseGroups(&e.Query.Query.Bool, groups)
+	if err != nil {
+		return
+	}
+	for _, r := range e.raw {
+		e.appendClause(&e.Query.Query.Bool, r.section, r.clause)
+	}
+	if e.maxClauses > 0 && e.clauseCount > e.maxClauses {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooManyClauses, e.clauseCount, e.maxClauses)
+	}
+	// finishBool moves the or alternatives next to soft clauses into a bool of
+	// their own, so they are counted here.
+	alternatives := len(e.Query.Query.Bool.Should)
+	e.finishBool(&e.Query.Query.Bool, e.minimumShouldMatch)
+	if e.flatten {
+		e.flattenBool(&e.Query.Query.Bool)
+	}
+	if e.autoNames != "" {
+		e.nameShould(&e.Query.Query.Bool)
+	}
+	err = e.checkBool(e.minimumShouldMatch, alternatives)
+	if err != nil {
+		return
+	}
+	e.report("build", &start)
 
+	// Numbers are decoded as json.Number so large integer values stay exact.
 	mQuery, _ := json.Marshal(e.Query)
-	err = json.Unmarshal(mQuery, &rs)
+	dec := json.NewDecoder(bytes.NewReader(mQuery))
+	dec.UseNumber()
+	err = dec.Decode(&rs)
+	if err != nil {
+		return
+	}
+	e.report("marshal", &start)
+	if knn != nil {
+		// The other conditions pre-filter the nearest neighbours instead of
+		// forming a query that would match and score documents on its own.
+		if len(e.decays) > 0 || len(e.pinnedIDs) > 0 {
+			return nil, ErrKNNQueryWrapper
+		}
+		if !emptyBool(&e.Query.Query.Bool) {
+			knn["filter"] = rs["query"]
+		}
+		delete(rs, "query")
+		rs["knn"] = knn
+		return rs, err
+	}
+	rs["query"] = e.wrapQuery(rs["query"])
 
 	return rs, err
 }
 
-func (e *Elastic) parseToDSLQuery(in Condition) (err error) {
-	operator := in.ComparisonOperators
-	logicalOperators := in.LogicalOperators
-	params, err := parseComparisonOperators(in)
+// prepare normalizes and validates the conditions and groups of e.
+func (e *Elastic) prepare() (in []Condition, groups []Group, err error) {
+	if e.optionErr != nil {
+		return nil, nil, e.optionErr
+	}
+	start := e.startStage()
+	in = toLower(e.Params, e.defaultLogical)
+	groups = toLowerGroups(e.Groups, e.defaultLogical)
+	e.report("toLower", &start)
+	err = validate(in)
 	if err != nil {
 		return
 	}
+	err = validateGroups(groups)
+	if err != nil {
+		return
+	}
+	err = e.validateNested(in, groups)
+	if err != nil {
+		return
+	}
+	if e.strictFieldNames {
+		err = validateFieldNames(in, groups)
+		if err != nil {
+			return
+		}
+	}
+	if e.allowedFields != nil || e.deniedFields != nil {
+		err = e.sanitize(in, groups)
+		if err != nil {
+			return
+		}
+	}
 
-	if contains(allowMustNot, operator) {
-		e.Query.Query.Bool.MustNot = append(e.Query.Query.Bool.MustNot, params)
+	err = e.collectWarnings(in, groups)
+	e.report("validate", &start)
+	return
+}
+
+// wrapQuery applies the query-level wrappers around the built bool query.
+func (e *Elastic) wrapQuery(query interface{}) interface{} {
+	if len(e.decays) == 1 {
+		fs := map[string]interface{}{
+			"query": query,
+		}
+		for k, v := range e.decays[0] {
+			fs[k] = v
+		}
+		query = map[string]interface{}{
+			"function_score": fs,
+		}
+	} else if len(e.decays) > 1 {
+		functions := make([]interface{}, len(e.decays))
+		for i, d := range e.decays {
+			functions[i] = d
+		}
+		query = map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query":     query,
+				"functions": functions,
+			},
+		}
+	}
+	if len(e.pinnedIDs) > 0 {
+		query = map[string]interface{}{
+			"pinned": map[string]interface{}{
+				"ids":     e.pinnedIDs,
+				"organic": query,
+			},
+		}
+	}
+	return query
+}
+
+func (e *Elastic) parseToDSLQuery(b *BoolQuery, in Condition) (err error) {
+	if skipped(in) {
+		return
+	}
+	operator := in.ComparisonOperators
+	in.Key = e.fieldName(in)
+	if len(e.fieldMap) > 0 && len(in.Keys) > 0 {
+		keys := make([]string, len(in.Keys))
+		for i, k := range in.Keys {
+			keys[i] = e.mapField(k)
+		}
+		in.Keys = keys
+	}
+	if in.Analyzer == "" {
+		in.Analyzer = e.analyzer
+	}
+	if e.trace != nil && e.traceIndex >= 0 {
+		section := sectionFor(in)
+		if b != &e.Query.Query.Bool {
+			// A WithGroupByKey alternative; its key bool is added to must.
+			section = "must"
+		}
+		*e.trace = append(*e.trace, ClauseTrace{
+			Index:    e.traceIndex,
+			Section:  section,
+			Operator: operator,
+			Key:      in.Key,
+		})
+	}
+	var params map[string]interface{}
+	if operator == "expr" {
+		params, err = parseExpr(in.Value.(string), e.mapField)
+	} else {
+		params, err = parseComparisonOperators(in)
+	}
+	if err != nil {
 		return
 	}
+	if (operator == "in" || operator == "nin") && e.chunkSize > 0 {
+		params = chunkTerms(params, in, e.chunkSize)
+	}
+	if in.Nested != "" {
+		params = map[string]interface{}{
+			"nested": map[string]interface{}{
+				"path":  in.Nested,
+				"query": params,
+			},
+		}
+	}
+	if in.ConstantScore != nil {
+		params = map[string]interface{}{
+			"constant_score": map[string]interface{}{
+				"filter": params,
+				"boost":  *in.ConstantScore,
+			},
+		}
+	}
+
+	before := e.clauseCount
+	if in.Soft {
+		e.appendSoft(b, params)
+	} else {
+		e.appendClause(b, sectionFor(in), params)
+	}
+	if e.clauseCount > before {
+		e.clauseCount += subClauses(params)
+	}
+	return
+}
 
+// subClauses returns the number of clauses in the bools a single condition
+// builds, such as a chunked terms list or an expr, looking through nested and
+// constant_score wrappers.
+func subClauses(clause interface{}) (n int) {
+	m, _ := clause.(map[string]interface{})
+	for op, body := range m {
+		switch op {
+		case "bool":
+			b, ok := body.(*BoolQuery)
+			if !ok {
+				continue
+			}
+			for _, section := range [][]interface{}{b.Must, b.Filter, b.MustNot, b.Should} {
+				for _, c := range section {
+					n += 1 + subClauses(c)
+				}
+			}
+		case "nested":
+			n += subClauses(body.(map[string]interface{})["query"])
+		case "constant_score":
+			n += subClauses(body.(map[string]interface{})["filter"])
+		}
+	}
+	return
+}
+
+// sectionFor returns the bool section a validated condition is routed to.
+func sectionFor(in Condition) string {
+	if contains(allowMustNot, in.ComparisonOperators) {
+		return "must_not"
+	}
+	if in.Soft || in.ComparisonOperators == "rank_feature" {
+		// rank_feature only adjusts scores, so it never filters.
+		return "should"
+	}
+	if in.LogicalOperators == "or" && !in.Required {
+		return "should"
+	}
+	if in.ComparisonOperators == "geo_polygon" {
+		// A shape never needs to score, so it is cached as a filter.
+		return "filter"
+	}
+	return "must"
+}
+
+// chunkTerms splits a terms clause whose value list is longer than size into a
+// should bool of terms clauses holding at most size values each.
+func chunkTerms(clause map[string]interface{}, in Condition, size int) map[string]interface{} {
+	v := reflect.ValueOf(in.Value)
+	if v.Kind() != reflect.Slice || v.Len() <= size {
+		return clause
+	}
+	var should []interface{}
+	for i := 0; i < v.Len(); i += size {
+		end := i + size
+		if end > v.Len() {
+			end = v.Len()
+		}
+		terms := map[string]interface{}{
+			in.Key: v.Slice(i, end).Interface(),
+		}
+		if in.Boost != nil {
+			terms["boost"] = *in.Boost
+		}
+		should = append(should, map[string]interface{}{
+			"terms": terms,
+		})
+	}
+	return map[string]interface{}{
+		"bool": &BoolQuery{Should: should},
+	}
+}
+
+// fieldName returns the physical field a condition queries.
+func (e *Elastic) fieldName(in Condition) string {
+	key := e.mapField(in.Key)
+	if e.keywordSuffix != "" && in.Type == "text" && (in.ComparisonOperators == "eq" || in.ComparisonOperators == "neq") &&
+		!strings.HasSuffix(key, e.keywordSuffix) {
+		key += e.keywordSuffix
+	}
+	return key
+}
+
+// mapField translates a logical key through WithFieldMap; unmapped keys are
+// returned as given.
+func (e *Elastic) mapField(key string) string {
+	if field, ok := e.fieldMap[key]; ok {
+		return field
+	}
+	return key
+}
+
+type rawClause struct {
+	section string
+	clause  map[string]interface{}
+}
+
+// AddRaw appends a hand-written clause to a section of the top-level bool query
+// (must, should, must_not or filter), for clauses the builder cannot express yet.
+func (e *Elastic) AddRaw(section string, clause map[string]interface{}) error {
+	if !contains(allowSection, section) {
+		return ErrInvalidSection
+	}
+	e.raw = append(e.raw, rawClause{section, clause})
+	return nil
+}
+
+// attach routes a positive clause into must or should by its logical operator.
+func (e *Elastic) attach(b *BoolQuery, logicalOperators string, clause map[string]interface{}) (err error) {
 	switch logicalOperators {
 	case "and":
-		e.Query.Query.Bool.Must = append(e.Query.Query.Bool.Must, params)
+		e.appendClause(b, "must", clause)
 		return
 	case "or":
-		e.Query.Query.Bool.Should = append(e.Query.Query.Bool.Should, params)
+		e.appendClause(b, "should", clause)
 		return
 	default:
-		err = errors.New("unsupported comparison operators")
+		err = errors.New("unsupported logical operators")
 	}
 	return
 }
 
+type seenKey struct {
+	bool    *BoolQuery
+	section string
+	clause  string
+}
+
+// appendClause adds clause to the given bool section, skipping clauses already
+// present in that section when deduplication is enabled. Should clauses that
+// differ only in boost are identical too; the one with the higher boost is
+// kept, in the position of the first.
+func (e *Elastic) appendClause(b *BoolQuery, section string, clause map[string]interface{}) {
+	if e.deduplicate {
+		if section == "should" {
+			unboosted, boost := splitBoost(clause)
+			key := seenKey{b, section, canonicalJSON(unboosted)}
+			if i, ok := e.seen[key]; ok {
+				if _, kept := splitBoost(b.Should[i]); boost > kept {
+					b.Should[i] = clause
+				}
+				return
+			}
+			e.seen[key] = len(b.Should)
+		} else {
+			key := seenKey{b, section, canonicalJSON(clause)}
+			if _, ok := e.seen[key]; ok {
+				return
+			}
+			e.seen[key] = 0
+		}
+	}
+	e.clauseCount++
+	switch section {
+	case "must":
+		b.Must = append(b.Must, clause)
+	case "filter":
+		b.Filter = append(b.Filter, clause)
+	case "must_not":
+		b.MustNot = append(b.MustNot, clause)
+	case "should":
+		b.Should = append(b.Should, clause)
+	}
+}
+
+// appendSoft holds a soft clause back until finishBool, which adds it to
+// should once the other should clauses have been made required.
+func (e *Elastic) appendSoft(b *BoolQuery, clause map[string]interface{}) {
+	if e.deduplicate {
+		key := seenKey{b, "soft", canonicalJSON(clause)}
+		if _, ok := e.seen[key]; ok {
+			return
+		}
+		e.seen[key] = 0
+	}
+	e.clauseCount++
+	e.soft[b] = append(e.soft[b], clause)
+}
+
+// finishBool applies the post-build passes to a fully populated bool query.
+// msm overrides minimum_should_match. Otherwise should clauses next to must or
+// filter clauses get an explicit 1, so at least one of them has to match;
+// without them it is left implicit.
+//
+// Soft clauses are optional and never count toward minimum_should_match: when
+// there are also or alternatives, those move into a must bool of their own
+// that carries msm, and a query of nothing but soft clauses matches all
+// documents.
+func (e *Elastic) finishBool(b *BoolQuery, msm interface{}) {
+	soft := e.soft[b]
+	if len(soft) > 0 {
+		if len(b.Should) > 0 {
+			if e.stableOrder {
+				sortClauses(b.Should)
+			}
+			alternatives := &BoolQuery{Should: b.Should, MinimumShouldMatch: msm}
+			if b == &e.Query.Query.Bool {
+				e.alternatives[alternatives] = true
+			}
+			b.Must = append(b.Must, map[string]interface{}{"bool": alternatives})
+		}
+		if len(b.Must) == 0 && len(b.Filter) == 0 {
+			b.Must = append(b.Must, map[string]interface{}{
+				"match_all": map[string]interface{}{},
+			})
+		}
+		b.Should = soft
+	} else if len(b.Should) > 0 {
+		if msm != nil {
+			b.MinimumShouldMatch = msm
+		} else if len(b.Must) > 0 || len(b.Filter) > 0 {
+			b.MinimumShouldMatch = 1
+		}
+	}
+	if e.stableOrder {
+		sortClauses(b.Must)
+		sortClauses(b.Filter)
+		sortClauses(b.MustNot)
+		sortClauses(b.Should)
+	}
+}
+
+func sortClauses(clauses []interface{}) {
+	sort.SliceStable(clauses, func(i, j int) bool {
+		return canonicalJSON(clauses[i]) < canonicalJSON(clauses[j])
+	})
+}
+
+// splitBoost returns clause without its boost and the boost, 1 when unset. The
+// boost is looked up in the query body and, for field queries, one level below.
+func splitBoost(clause interface{}) (interface{}, float64) {
+	m, ok := clause.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return clause, 1
+	}
+	for name, body := range m {
+		rest, boost, found := withoutBoost(body)
+		if !found {
+			if fields, ok := body.(map[string]interface{}); ok && len(fields) == 1 {
+				for field, v := range fields {
+					var inner interface{}
+					inner, boost, found = withoutBoost(v)
+					if found {
+						rest = map[string]interface{}{field: shortForm(inner)}
+					}
+				}
+			}
+		}
+		if !found {
+			return clause, 1
+		}
+		return map[string]interface{}{name: rest}, boost
+	}
+	return clause, 1
+}
+
+// shortForm collapses a field body left with only its value, such as
+// {"value": v} of term or {"query": q} of match, to v, so it compares equal to
+// the clause written without a boost.
+func shortForm(body interface{}) interface{} {
+	if m, ok := body.(map[string]interface{}); ok && len(m) == 1 {
+		if v, ok := m["value"]; ok {
+			return v
+		}
+		if v, ok := m["query"]; ok {
+			return v
+		}
+	}
+	return body
+}
+
+// withoutBoost returns a copy of body without its boost key, if body is an
+// object with a numeric boost.
+func withoutBoost(body interface{}) (interface{}, float64, bool) {
+	m, ok := body.(map[string]interface{})
+	if !ok {
+		return body, 0, false
+	}
+	var boost float64
+	switch v := m["boost"].(type) {
+	case float64:
+		boost = v
+	case int:
+		boost = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return body, 0, false
+		}
+		boost = f
+	default:
+		return body, 0, false
+	}
+	rest := make(map[string]interface{}, len(m)-1)
+	for k, v := range m {
+		if k != "boost" {
+			rest[k] = v
+		}
+	}
+	return rest, boost, true
+}
+
+// canonicalJSON relies on json.Marshal writing map keys in sorted order.
+func canonicalJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// exactNumbers converts integer values, alone or in a slice, to json.Number so
+// they are not rounded through float64 on the way to JSON.
+func exactNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return v
+	case int:
+		return json.Number(strconv.FormatInt(int64(v), 10))
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10))
+	case uint:
+		return json.Number(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10))
+	case []int64, []uint64, []int, []uint, []json.Number, []interface{}:
+		rv := reflect.ValueOf(v)
+		rs := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			rs[i] = exactNumbers(rv.Index(i).Interface())
+		}
+		return rs
+	}
+	return value
+}
+
+func isNumeric(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	case json.Number:
+		_, err := v.Float64()
+		return err == nil
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 func parseComparisonOperators(in Condition) (rs map[string]interface{}, err error) {
 	rs = make(map[string]interface{})
 	var operator, key = in.ComparisonOperators, in.Key
-	var value = in.Value
+	var value = exactNumbers(in.Value)
 	switch operator {
 	case "eq", "neq":
+		if in.Boost == nil {
+			rs["term"] = map[string]interface{}{
+				key: value,
+			}
+			return
+		}
 		rs["term"] = map[string]interface{}{
-			key: value,
+			key: map[string]interface{}{
+				"value": value,
+				"boost": *in.Boost,
+			},
+		}
+		return
+	case "wildcard", "regexp":
+		// On analyzed text fields patterns match single tokens, not the whole value.
+		if in.Boost == nil {
+			rs[operator] = map[string]interface{}{
+				key: value,
+			}
+			return
+		}
+		rs[operator] = map[string]interface{}{
+			key: map[string]interface{}{
+				"value": value,
+				"boost": *in.Boost,
+			},
 		}
 		return
+	case "starts_with", "nstarts_with", "ends_with", "nends_with", "contains", "ncontains":
+		pattern := escapeWildcard(value.(string))
+		switch strings.TrimPrefix(operator, "n") {
+		case "starts_with":
+			pattern += "*"
+		case "ends_with":
+			pattern = "*" + pattern
+		default:
+			pattern = "*" + pattern + "*"
+		}
+		in.ComparisonOperators, in.Value = "wildcard", pattern
+		return parseComparisonOperators(in)
 	case "in", "nin":
-		rs["terms"] = map[string]interface{}{
+		terms := map[string]interface{}{
 			key: value,
 		}
+		if in.Boost != nil {
+			terms["boost"] = *in.Boost
+		}
+		rs["terms"] = terms
 		return
 	case "like", "nlike":
+		if in.Boost == nil && in.Fuzziness == "" && in.PrefixLength == nil && in.MaxExpansions == nil && in.ZeroTerms == "" && in.Analyzer == "" && in.AutoSynonyms == nil && in.Lenient == nil {
+			rs["match"] = map[string]interface{}{
+				key: value,
+			}
+			return
+		}
+		body := map[string]interface{}{
+			"query": value,
+		}
+		if in.Fuzziness != "" {
+			body["fuzziness"] = in.Fuzziness
+		}
+		if in.PrefixLength != nil {
+			body["prefix_length"] = *in.PrefixLength
+		}
+		if in.MaxExpansions != nil {
+			body["max_expansions"] = *in.MaxExpansions
+		}
+		if in.ZeroTerms != "" {
+			body["zero_terms_query"] = in.ZeroTerms
+		}
+		if in.Analyzer != "" {
+			body["analyzer"] = in.Analyzer
+		}
+		if in.AutoSynonyms != nil {
+			body["auto_generate_synonyms_phrase_query"] = *in.AutoSynonyms
+		}
+		if in.Lenient != nil {
+			body["lenient"] = *in.Lenient
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
 		rs["match"] = map[string]interface{}{
-			key: value,
+			key: body,
 		}
 		return
-	case "lt", "lte", "gt", "gte":
-		rs["range"] = map[string]interface{}{
+	case "phrase", "nphrase":
+		if in.Slop == nil && in.Boost == nil && in.ZeroTerms == "" && in.Analyzer == "" {
+			rs["match_phrase"] = map[string]interface{}{
+				key: value,
+			}
+			return
+		}
+		body := map[string]interface{}{
+			"query": value,
+		}
+		if in.Slop != nil {
+			body["slop"] = *in.Slop
+		}
+		if in.ZeroTerms != "" {
+			body["zero_terms_query"] = in.ZeroTerms
+		}
+		if in.Analyzer != "" {
+			body["analyzer"] = in.Analyzer
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["match_phrase"] = map[string]interface{}{
+			key: body,
+		}
+		return
+	case "match_bool_prefix", "nmatch_bool_prefix":
+		// Every term but the last is matched exactly; the last is a prefix.
+		if in.Boost == nil && in.Analyzer == "" {
+			rs["match_bool_prefix"] = map[string]interface{}{
+				key: value,
+			}
+			return
+		}
+		body := map[string]interface{}{
+			"query": value,
+		}
+		if in.Analyzer != "" {
+			body["analyzer"] = in.Analyzer
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["match_bool_prefix"] = map[string]interface{}{
+			key: body,
+		}
+		return
+	case "intervals":
+		if rule, ok := value.(IntervalsRule); ok {
+			rs["intervals"] = map[string]interface{}{
+				key: rule.toDSL(),
+			}
+			return
+		}
+		match := map[string]interface{}{
+			"query": value,
+		}
+		if in.MaxGaps != nil {
+			match["max_gaps"] = *in.MaxGaps
+		}
+		if in.Ordered {
+			match["ordered"] = true
+		}
+		rs["intervals"] = map[string]interface{}{
 			key: map[string]interface{}{
-				operator: value,
+				"match": match,
 			},
 		}
 		return
+	case "combined_fields":
+		// combined_fields treats all fields as one, so every field must use the same analyzer.
+		body := map[string]interface{}{
+			"query":  value,
+			"fields": in.Keys,
+		}
+		if in.Operator != "" {
+			body["operator"] = in.Operator
+		}
+		if in.ZeroTerms != "" {
+			body["zero_terms_query"] = in.ZeroTerms
+		}
+		if in.AutoSynonyms != nil {
+			body["auto_generate_synonyms_phrase_query"] = *in.AutoSynonyms
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["combined_fields"] = body
+		return
+	case "geo_polygon":
+		points, _ := polygonPoints(in.Value)
+		rs["geo_polygon"] = map[string]interface{}{
+			key: map[string]interface{}{
+				"points": points,
+			},
+		}
+		return
+	case "geo_distance":
+		body := map[string]interface{}{
+			"distance": in.Distance,
+			key:        value,
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["geo_distance"] = body
+		return
+	case "more_like_this":
+		// Value is the like text or a map with "like" plus options such as
+		// min_term_freq and max_query_terms.
+		body := map[string]interface{}{
+			"fields": in.Keys,
+		}
+		if opts, ok := value.(map[string]interface{}); ok {
+			for k, v := range opts {
+				body[k] = v
+			}
+		} else {
+			body["like"] = value
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["more_like_this"] = body
+		return
+	case "rank_feature":
+		body := map[string]interface{}{
+			"field": key,
+		}
+		for fn, params := range value.(map[string]interface{}) {
+			body[fn] = params
+		}
+		if in.Boost != nil {
+			body["boost"] = *in.Boost
+		}
+		rs["rank_feature"] = body
+		return
+	case "lt", "lte", "gt", "gte":
+		bounds := map[string]interface{}{
+			operator: value,
+		}
+		if in.Relation != "" {
+			bounds["relation"] = in.Relation
+		}
+		if in.Boost != nil {
+			bounds["boost"] = *in.Boost
+		}
+		rs["range"] = map[string]interface{}{
+			key: bounds,
+		}
+		return
 	default:
 		err = errors.New("unsupported comparison operators")
 	}
@@ -179,6 +1119,9 @@ func parseComparisonOperators(in Condition) (rs map[string]interface{}, err erro
 func validate(in []Condition) (err error) {
 	for i := 0; i < len(in); i++ {
 		cond := in[i]
+		if skipped(cond) {
+			continue
+		}
 		if !contains(allowType, cond.Type) {
 			err = errors.New("unsupported data type")
 			break
@@ -187,6 +1130,34 @@ func validate(in []Condition) (err error) {
 			err = errors.New("unsupported logical operators")
 			break
 		}
+		if cond.Boost != nil && *cond.Boost < 0 {
+			err = ErrNegativeBoost
+			break
+		}
+		if cond.Soft && (cond.Required || contains(allowMustNot, cond.ComparisonOperators)) {
+			err = ErrInvalidSoft
+			break
+		}
+		if cond.ConstantScore != nil && (*cond.ConstantScore < 0 || contains(allowMustNot, cond.ComparisonOperators)) {
+			err = ErrInvalidConstantScore
+			break
+		}
+		if cond.AutoSynonyms != nil && !contains([]string{"like", "nlike", "combined_fields"}, cond.ComparisonOperators) {
+			err = fmt.Errorf("%w: %s", ErrInvalidAutoSynonyms, cond.ComparisonOperators)
+			break
+		}
+		if cond.Lenient != nil && cond.ComparisonOperators != "like" && cond.ComparisonOperators != "nlike" {
+			err = fmt.Errorf("%w: %s", ErrInvalidLenient, cond.ComparisonOperators)
+			break
+		}
+		if cond.Relation != "" && cond.Type != "range" {
+			err = fmt.Errorf("%w: type %s", ErrInvalidRelation, cond.Type)
+			break
+		}
+		if !contains(allowZeroTerms, cond.ZeroTerms) {
+			err = fmt.Errorf("%w: %q", ErrInvalidZeroTerms, cond.ZeroTerms)
+			break
+		}
 
 		condComparisonOperators := cond.ComparisonOperators
 		switch cond.Type {
@@ -195,16 +1166,72 @@ func validate(in []Condition) (err error) {
 				err = errors.New("unsupported comparison operators for text")
 				break
 			}
+			switch condComparisonOperators {
+			case "like", "nlike":
+				if cond.PrefixLength != nil && *cond.PrefixLength < 0 {
+					err = ErrInvalidPrefixLength
+				} else if cond.MaxExpansions != nil && *cond.MaxExpansions <= 0 {
+					err = ErrInvalidMaxExpansions
+				}
+			case "phrase", "nphrase":
+				if cond.Slop != nil && *cond.Slop < 0 {
+					err = ErrInvalidSlop
+				}
+			case "match_bool_prefix", "nmatch_bool_prefix":
+				if v, ok := cond.Value.(string); !ok || v == "" {
+					err = ErrInvalidMatchBoolPrefix
+				}
+			case "intervals":
+				if cond.MaxGaps != nil && *cond.MaxGaps < -1 {
+					err = ErrInvalidMaxGaps
+				} else if rule, ok := cond.Value.(IntervalsRule); ok {
+					err = rule.validate()
+				}
+			case "more_like_this":
+				err = validateFields(cond.Keys)
+				if err == nil && !hasLike(cond.Value) {
+					err = ErrMissingLike
+				}
+			case "combined_fields":
+				err = validateFields(cond.Keys)
+				if err == nil && !contains(allowTermOperators, cond.Operator) {
+					err = errors.New("unsupported operator for combined_fields")
+				}
+			}
+			break
+		case "keyword":
+			if !contains(allowKeyword, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for keyword")
+				break
+			}
+			switch condComparisonOperators {
+			case "starts_with", "nstarts_with", "ends_with", "nends_with", "contains", "ncontains":
+				if v, ok := cond.Value.(string); !ok || v == "" {
+					err = ErrInvalidPatternValue
+				}
+			}
 			break
 		case "number":
 			if !contains(allowNumber, condComparisonOperators) {
 				err = errors.New("unsupported comparison operators for number")
 				break
 			}
+			if condComparisonOperators == "rank_feature" {
+				err = validateRankFeature(cond.Value)
+				break
+			}
 
-			_, err := strconv.ParseFloat(cond.Value.(string), 32)
-			if err != nil {
-				err = errors.New("prams invalid")
+			if !isNumeric(cond.Value) {
+				err = errors.New("params invalid")
+			}
+			break
+		case "boolean":
+			if !contains(allowBoolean, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for boolean")
+				break
+			}
+			if _, ok := cond.Value.(bool); !ok {
+				err = errors.New("params invalid")
 			}
 			break
 		case "array":
@@ -212,6 +1239,9 @@ func validate(in []Condition) (err error) {
 				err = errors.New("unsupported comparison operators for array")
 				break
 			}
+			if lookup, ok := cond.Value.(map[string]interface{}); ok {
+				err = validateTermsLookup(lookup)
+			}
 			break
 		case "date":
 			if !contains(allowDate, condComparisonOperators) {
@@ -219,6 +1249,210 @@ func validate(in []Condition) (err error) {
 				break
 			}
 			break
+		case "range":
+			if !contains(allowRange, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for range")
+				break
+			}
+			if !contains(allowRelation, cond.Relation) {
+				err = ErrInvalidRelation
+			}
+			break
+		case "expr":
+			if !contains(allowExpr, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for expr")
+				break
+			}
+			if s, ok := cond.Value.(string); ok {
+				_, err = parseExpr(s, func(f string) string { return f })
+			} else {
+				err = fmt.Errorf("%w: value must be a string", ErrInvalidExpr)
+			}
+			break
+		case "vector":
+			if !contains(allowVector, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for vector")
+				break
+			}
+			err = validateKNN(cond.Value)
+			break
+		case "ip":
+			if !contains(allowIP, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for ip")
+				break
+			}
+			if !validIP(cond.Value, condComparisonOperators == "eq" || condComparisonOperators == "neq") {
+				err = fmt.Errorf("%w: %v", ErrInvalidIP, cond.Value)
+			}
+			break
+		case "geo":
+			if !contains(allowGeo, condComparisonOperators) {
+				err = errors.New("unsupported comparison operators for geo")
+				break
+			}
+			if condComparisonOperators == "geo_polygon" {
+				err = validatePolygon(cond.Value)
+			} else if !distancePattern.MatchString(cond.Distance) {
+				err = fmt.Errorf("%w: %q", ErrInvalidDistanceUnit, cond.Distance)
+			}
+			break
+		}
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+func (e *Elastic) validateNested(in []Condition, groups []Group) (err error) {
+	if len(e.NestedPaths) == 0 {
+		return
+	}
+	for i := 0; i < len(in); i++ {
+		cond := in[i]
+		if cond.Nested != "" {
+			continue
+		}
+		for _, key := range append([]string{cond.Key}, cond.Keys...) {
+			for _, path := range e.NestedPaths {
+				if key == path || strings.HasPrefix(key, path+".") {
+					return fmt.Errorf("%w: %s is under %s", ErrNeedsNestedWrapper, key, path)
+				}
+			}
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		err = e.validateNested(groups[i].Conditions, groups[i].Groups)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func validateFieldNames(in []Condition, groups []Group) (err error) {
+	for i := 0; i < len(in); i++ {
+		cond := in[i]
+		keys := append([]string{cond.Key, cond.Nested}, cond.Keys...)
+		if s, ok := cond.Value.(string); ok && cond.Type == "expr" {
+			// validate has already checked the syntax.
+			parseExpr(s, func(f string) string {
+				keys = append(keys, f)
+				return f
+			})
+		}
+		for _, key := range keys {
+			if key != "" && !fieldNamePattern.MatchString(key) {
+				return fmt.Errorf("%w: %q", ErrInvalidFieldName, key)
+			}
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		err = validateFieldNames(groups[i].Conditions, groups[i].Groups)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// validateRankFeature checks that value holds exactly one scoring function,
+// e.g. {"saturation": {"pivot": 8}}.
+func validateRankFeature(value interface{}) error {
+	fns, ok := value.(map[string]interface{})
+	if !ok || len(fns) != 1 {
+		return ErrInvalidRankFeature
+	}
+	for fn := range fns {
+		if !contains(allowRankFeatureFunctions, fn) {
+			return ErrInvalidRankFeature
+		}
+	}
+	return nil
+}
+
+func hasLike(value interface{}) bool {
+	if opts, ok := value.(map[string]interface{}); ok {
+		value = opts["like"]
+	}
+	if value == nil || value == "" {
+		return false
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() != reflect.Slice || v.Len() > 0
+}
+
+// validIP reports whether value is an IP address, or also a CIDR block when
+// cidr is set; term queries on ip fields accept both.
+func validIP(value interface{}, cidr bool) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	if cidr {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	}
+	return false
+}
+
+// validateTermsLookup checks the terms lookup form of in and nin, which takes
+// its values from a field of another document. The lookup is sent as given, so
+// optional keys such as routing are kept.
+func validateTermsLookup(lookup map[string]interface{}) error {
+	for _, k := range []string{"index", "id", "path"} {
+		if v, ok := lookup[k]; !ok || v == nil || v == "" {
+			return fmt.Errorf("%w: missing %q", ErrIncompleteTermsLookup, k)
+		}
+	}
+	return nil
+}
+
+// escapeWildcard escapes the wildcard metacharacters *, ? and \ in s.
+func escapeWildcard(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == '*' || r == '?' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// skipped reports whether a SkipIfEmpty condition has an empty value: nil, an
+// empty string (text, keyword, number, date, range, geo) or an empty slice or
+// map (array and structured values). Zero numbers and false are not empty.
+func skipped(in Condition) bool {
+	if in.denied {
+		return true
+	}
+	if !in.SkipIfEmpty {
+		return false
+	}
+	if in.Value == nil || in.Value == "" {
+		return true
+	}
+	v := reflect.ValueOf(in.Value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func validateFields(keys []string) (err error) {
+	if len(keys) == 0 {
+		return errors.New("fields must not be empty")
+	}
+	for _, k := range keys {
+		if k == "" {
+			return errors.New("fields must not contain an empty name")
 		}
 	}
 	return
@@ -242,20 +1476,59 @@ func contains(s []string, e string) bool {
 	return false
 }
 
-func toLower(in []Condition) (rs []Condition) {
+// logicalOperators normalizes a logical operator; an empty one takes def, or
+// "and" when no default is configured.
+func logicalOperators(s, def string) string {
+	if s == "" {
+		s = def
+	}
+	if s == "" {
+		return "and"
+	}
+	return strings.ToLower(s)
+}
+
+// inferType returns the condition type for an auto or empty Type. Strings are
+// text even when they hold a number or a date; set Type to query them as such.
+// Values of other kinds are left as auto, which does not validate.
+func inferType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		return "text"
+	case time.Time, *time.Time:
+		return "date"
+	case KNN:
+		return "vector"
+	case json.Number:
+		return "number"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "auto"
+}
+
+func toLower(in []Condition, defaultLogical string) (rs []Condition) {
 	rs = make([]Condition, len(in))
 	for i := 0; i < len(in); i++ {
 		cond := in[i]
-		condType := strings.ToLower(cond.Type)
-		condLogicalOperators := strings.ToLower(cond.LogicalOperators)
-		condComparisonOperators := strings.ToLower(cond.ComparisonOperators)
-		rs[i] = Condition{
-			Type:                condType,
-			ComparisonOperators: condComparisonOperators,
-			LogicalOperators:    condLogicalOperators,
-			Key:                 cond.Key,
-			Value:               cond.Value,
+		cond.Type = strings.ToLower(cond.Type)
+		if cond.Type == "" || cond.Type == "auto" {
+			cond.Type = inferType(cond.Value)
 		}
+		cond.ComparisonOperators = strings.ToLower(cond.ComparisonOperators)
+		cond.LogicalOperators = logicalOperators(cond.LogicalOperators, defaultLogical)
+		cond.Operator = strings.ToLower(cond.Operator)
+		cond.Relation = strings.ToLower(cond.Relation)
+		cond.Distance = strings.Join(strings.Fields(cond.Distance), "")
+		rs[i] = cond
 	}
 	return
 }
@@ -0,0 +1,576 @@
+package elastic
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cond(typ, op, logical, key string, value interface{}) Condition {
+	return Condition{Type: typ, ComparisonOperators: op, LogicalOperators: logical, Key: key, Value: value}
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func intPtr(n int) *int { return &n }
+
+// queryJSON builds e and returns the query in compact JSON, failing the test
+// on a build error.
+func queryJSON(t *testing.T, e *Elastic) string {
+	t.Helper()
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		t.Fatalf("ParseToQuery: %v", err)
+	}
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return string(b)
+}
+
+func TestDeduplicate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []Condition
+		want string
+	}{
+		{
+			name: "exact duplicates",
+			in: []Condition{
+				cond("keyword", "eq", "and", "a", "x"),
+				cond("keyword", "eq", "and", "a", "x"),
+			},
+			want: `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`,
+		},
+		{
+			name: "duplicate should clauses",
+			in: []Condition{
+				cond("keyword", "eq", "or", "a", "x"),
+				cond("keyword", "eq", "or", "b", "y"),
+				cond("keyword", "eq", "or", "a", "x"),
+			},
+			want: `{"query":{"bool":{"should":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`,
+		},
+		{
+			name: "different value",
+			in: []Condition{
+				cond("keyword", "eq", "and", "a", "x"),
+				cond("keyword", "eq", "and", "a", "y"),
+			},
+			want: `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"a":"y"}}]}}}`,
+		},
+		{
+			name: "different operator",
+			in: []Condition{
+				cond("text", "eq", "and", "a", "x"),
+				cond("text", "like", "and", "a", "x"),
+			},
+			want: `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"match":{"a":"x"}}]}}}`,
+		},
+		{
+			name: "different section",
+			in: []Condition{
+				cond("keyword", "eq", "and", "a", "x"),
+				cond("keyword", "eq", "or", "a", "x"),
+			},
+			want: `{"query":{"bool":{"minimum_should_match":1,"must":[{"term":{"a":"x"}}],"should":[{"term":{"a":"x"}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := queryJSON(t, New(tt.in).WithDeduplicate())
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeduplicateOff(t *testing.T) {
+	in := []Condition{
+		cond("keyword", "eq", "and", "a", "x"),
+		cond("keyword", "eq", "and", "a", "x"),
+	}
+	want := `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"a":"x"}}]}}}`
+	if got := queryJSON(t, New(in)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestDeduplicateShouldBoosts(t *testing.T) {
+	boosted := func(logical string, boost float64) Condition {
+		c := cond("keyword", "eq", logical, "a", "x")
+		c.Boost = floatPtr(boost)
+		return c
+	}
+	tests := []struct {
+		name string
+		in   []Condition
+		want string
+	}{
+		{"higher last", []Condition{boosted("or", 2), boosted("or", 5)}, `{"query":{"bool":{"should":[{"term":{"a":{"boost":5,"value":"x"}}}]}}}`},
+		{"higher first", []Condition{boosted("or", 5), boosted("or", 2)}, `{"query":{"bool":{"should":[{"term":{"a":{"boost":5,"value":"x"}}}]}}}`},
+		{"keeps position", []Condition{boosted("or", 2), cond("keyword", "eq", "or", "b", "y"), boosted("or", 5)}, `{"query":{"bool":{"should":[{"term":{"a":{"boost":5,"value":"x"}}},{"term":{"b":"y"}}]}}}`},
+		{"unboosted", []Condition{cond("keyword", "eq", "or", "a", "x"), boosted("or", 2)}, `{"query":{"bool":{"should":[{"term":{"a":{"boost":2,"value":"x"}}}]}}}`},
+		{"must is not merged", []Condition{boosted("and", 5), boosted("and", 3)}, `{"query":{"bool":{"must":[{"term":{"a":{"boost":5,"value":"x"}}},{"term":{"a":{"boost":3,"value":"x"}}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, New(tt.in).WithDeduplicate()); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmptyLogicalOperator(t *testing.T) {
+	tests := []struct {
+		name    string
+		logical string
+		want    string
+		err     bool
+	}{
+		{name: "empty", logical: "", want: `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+		{name: "and", logical: "and", want: `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+		{name: "upper case", logical: "AND", want: `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+		{name: "unknown", logical: "xor", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{cond("keyword", "eq", tt.logical, "a", "x")})
+			if tt.err {
+				if _, err := e.ParseToQuery(); err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldBoosts(t *testing.T) {
+	exact := cond("keyword", "eq", "or", "name", "dvt")
+	exact.Boost = floatPtr(3)
+	fuzzy := cond("text", "like", "or", "name", "dvt")
+	fuzzy.Fuzziness = "AUTO"
+	fuzzy.Boost = floatPtr(0.5)
+	plain := cond("text", "phrase", "or", "name", "d v t")
+
+	want := `{"query":{"bool":{"should":[` +
+		`{"term":{"name":{"boost":3,"value":"dvt"}}},` +
+		`{"match":{"name":{"boost":0.5,"fuzziness":"AUTO","query":"dvt"}}},` +
+		`{"match_phrase":{"name":"d v t"}}]}}}`
+	if got := queryJSON(t, New([]Condition{exact, fuzzy, plain})); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestLargeNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Condition
+		want string
+	}{
+		{"int64", cond("number", "eq", "and", "id", int64(9007199254740993)), `{"query":{"bool":{"must":[{"term":{"id":9007199254740993}}]}}}`},
+		{"uint64", cond("number", "eq", "and", "id", uint64(18446744073709551615)), `{"query":{"bool":{"must":[{"term":{"id":18446744073709551615}}]}}}`},
+		{"json.Number", cond("number", "gt", "and", "id", json.Number("9007199254740993")), `{"query":{"bool":{"must":[{"range":{"id":{"gt":9007199254740993}}}]}}}`},
+		{"terms", cond("array", "in", "and", "id", []int64{9007199254740993, 9007199254740995}), `{"query":{"bool":{"must":[{"terms":{"id":[9007199254740993,9007199254740995]}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New([]Condition{tt.in}).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got  %s\nwant %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldOnlyExplicitMinimumShouldMatch(t *testing.T) {
+	in := []Condition{
+		cond("keyword", "eq", "or", "a", "x"),
+		cond("keyword", "eq", "or", "b", "y"),
+	}
+	want := `{"query":{"bool":{"minimum_should_match":1,"should":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`
+	if got := queryJSON(t, New(in).WithMinimumShouldMatch(1)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	want = `{"query":{"bool":{"minimum_should_match":"1","should":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`
+	if got := queryJSON(t, New(in).WithMinimumShouldMatch("1")); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRequiredShould(t *testing.T) {
+	required := cond("keyword", "eq", "or", "category", "shoes")
+	required.Required = true
+	tests := []struct {
+		name string
+		in   []Condition
+		want string
+	}{
+		{
+			name: "required with optional boosts",
+			in:   []Condition{required, cond("keyword", "eq", "or", "brand", "a"), cond("keyword", "eq", "or", "brand", "b")},
+			want: `{"query":{"bool":{"minimum_should_match":1,"must":[{"term":{"category":"shoes"}}],"should":[{"term":{"brand":"a"}},{"term":{"brand":"b"}}]}}}`,
+		},
+		{
+			name: "required only",
+			in:   []Condition{required},
+			want: `{"query":{"bool":{"must":[{"term":{"category":"shoes"}}]}}}`,
+		},
+		{
+			name: "required next to and",
+			in:   []Condition{cond("keyword", "eq", "and", "active", "yes"), required, cond("keyword", "eq", "or", "brand", "a")},
+			want: `{"query":{"bool":{"minimum_should_match":1,"must":[{"term":{"active":"yes"}},{"term":{"category":"shoes"}}],"should":[{"term":{"brand":"a"}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, New(tt.in)); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTermsOrder(t *testing.T) {
+	values := []string{"c", "a", "b", "a"}
+	tests := []struct {
+		name string
+		e    *Elastic
+		want string
+	}{
+		{"in", New([]Condition{cond("array", "in", "and", "k", values)}), `{"query":{"bool":{"must":[{"terms":{"k":["c","a","b","a"]}}]}}}`},
+		{"nin", New([]Condition{cond("array", "nin", "and", "k", values)}), `{"query":{"bool":{"must_not":[{"terms":{"k":["c","a","b","a"]}}]}}}`},
+		{"stable order", New([]Condition{cond("array", "in", "and", "k", values)}).WithStableOrder(), `{"query":{"bool":{"must":[{"terms":{"k":["c","a","b","a"]}}]}}}`},
+		{"deduplicate", New([]Condition{cond("array", "in", "and", "k", values), cond("array", "in", "and", "k", values)}).WithDeduplicate(), `{"query":{"bool":{"must":[{"terms":{"k":["c","a","b","a"]}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, tt.e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]string
+		want string
+	}{
+		{"single", map[string]string{"a": "x"}, `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+		{"sorted keys", map[string]string{"c": "3", "a": "1", "b": "2"}, `{"query":{"bool":{"must":[{"term":{"a":"1"}},{"term":{"b":"2"}},{"term":{"c":"3"}}]}}}`},
+		{"empty", map[string]string{}, `{"query":{"bool":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				if got := queryJSON(t, FromMap(tt.m)); got != tt.want {
+					t.Fatalf("got  %s\nwant %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFromMapConditions(t *testing.T) {
+	e := FromMap(map[string]string{"b": "2", "a": "1"})
+	want := []Condition{cond("text", "eq", "and", "a", "1"), cond("text", "eq", "and", "b", "2")}
+	if !reflect.DeepEqual(e.Params, want) {
+		t.Errorf("got %+v, want %+v", e.Params, want)
+	}
+}
+
+func TestSkipIfEmpty(t *testing.T) {
+	var nilTime *time.Time
+	keep := cond("keyword", "eq", "and", "keep", "k")
+	tests := []struct {
+		name    string
+		in      Condition
+		skipped bool
+	}{
+		{"nil", cond("keyword", "eq", "and", "a", nil), true},
+		{"empty string", cond("text", "like", "and", "a", ""), true},
+		{"empty slice", cond("array", "in", "and", "a", []string{}), true},
+		{"nil slice", cond("array", "in", "and", "a", []int(nil)), true},
+		{"empty map", cond("array", "in", "and", "a", map[string]interface{}{}), true},
+		{"nil pointer", cond("date", "gte", "and", "a", nilTime), true},
+		{"zero number", cond("number", "eq", "and", "a", 0), false},
+		{"false", cond("boolean", "eq", "and", "a", false), false},
+		{"non-empty string", cond("keyword", "eq", "and", "a", "x"), false},
+		{"non-empty slice", cond("array", "in", "and", "a", []string{"x"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.in
+			c.SkipIfEmpty = true
+			got := queryJSON(t, New([]Condition{keep, c}))
+			only := `{"query":{"bool":{"must":[{"term":{"keep":"k"}}]}}}`
+			if (got == only) != tt.skipped {
+				t.Errorf("skipped = %v, want %v: %s", got == only, tt.skipped, got)
+			}
+		})
+	}
+}
+
+func TestSkipIfEmptyOff(t *testing.T) {
+	want := `{"query":{"bool":{"must":[{"term":{"a":""}}]}}}`
+	if got := queryJSON(t, New([]Condition{cond("keyword", "eq", "and", "a", "")})); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMinimumShouldMatchDefaults(t *testing.T) {
+	must := cond("keyword", "eq", "and", "a", "x")
+	should := cond("keyword", "eq", "or", "b", "y")
+	polygon := Condition{Type: "geo", ComparisonOperators: "geo_polygon", LogicalOperators: "and", Key: "loc", Value: []GeoPoint{{1, 2}, {3, 4}, {5, 6}}}
+	tests := []struct {
+		name string
+		in   []Condition
+		msm  interface{}
+		want interface{} // nil when minimum_should_match is left out
+	}{
+		{name: "should only", in: []Condition{should}},
+		{name: "should and must", in: []Condition{must, should}, want: json.Number("1")},
+		{name: "should only, overridden", in: []Condition{should}, msm: 2, want: json.Number("2")},
+		{name: "should and must, overridden", in: []Condition{must, should}, msm: "75%", want: "75%"},
+		{name: "should and filter", in: []Condition{polygon, should}, want: json.Number("1")},
+		{name: "should and must_not", in: []Condition{cond("keyword", "neq", "and", "a", "x"), should}},
+		{name: "must only", in: []Condition{must}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.in)
+			if tt.msm != nil {
+				e.WithMinimumShouldMatch(tt.msm)
+			}
+			b := builtQuery(t, e).(map[string]interface{})["bool"].(map[string]interface{})
+			got, ok := b["minimum_should_match"]
+			if ok != (tt.want != nil) || ok && got != tt.want {
+				t.Errorf("minimum_should_match = %v (present %v), want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoft(t *testing.T) {
+	soft := cond("keyword", "eq", "and", "s", "x")
+	soft.Soft = true
+	tests := []struct {
+		name  string
+		in    []Condition
+		msm   interface{}
+		want  string
+		match func(a, b bool) bool
+	}{
+		{
+			name:  "alone",
+			in:    []Condition{soft},
+			want:  `{"query":{"bool":{"must":[{"match_all":{}}],"should":[{"term":{"s":"x"}}]}}}`,
+			match: func(a, b bool) bool { return true },
+		},
+		{
+			name:  "with must",
+			in:    []Condition{cond("keyword", "eq", "and", "a", "x"), soft},
+			want:  `{"query":{"bool":{"must":[{"term":{"a":"x"}}],"should":[{"term":{"s":"x"}}]}}}`,
+			match: func(a, b bool) bool { return a },
+		},
+		{
+			name:  "with should",
+			in:    []Condition{cond("keyword", "eq", "or", "a", "x"), cond("keyword", "eq", "or", "b", "x"), soft},
+			want:  `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}],"should":[{"term":{"s":"x"}}]}}}`,
+			match: func(a, b bool) bool { return a || b },
+		},
+		{
+			name:  "with should and minimum_should_match",
+			in:    []Condition{cond("keyword", "eq", "or", "a", "x"), cond("keyword", "eq", "or", "b", "x"), soft},
+			msm:   2,
+			want:  `{"query":{"bool":{"must":[{"bool":{"minimum_should_match":2,"should":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}],"should":[{"term":{"s":"x"}}]}}}`,
+			match: func(a, b bool) bool { return a && b },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.in)
+			if tt.msm != nil {
+				e.WithMinimumShouldMatch(tt.msm)
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			query := builtQuery(t, e)
+			for _, doc := range docs([]string{"a", "b", "s"}, "x", "y") {
+				want := tt.match(doc["a"] == "x", doc["b"] == "x")
+				if got := matches(t, query, doc); got != want {
+					t.Errorf("doc %v: got %v, want %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSoftConflictDetection(t *testing.T) {
+	soft := cond("keyword", "eq", "and", "a", "y")
+	soft.Soft = true
+	e := New([]Condition{cond("keyword", "eq", "and", "a", "x"), soft}).WithConflictDetection()
+	if _, err := e.ParseToQuery(); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := e.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none", warnings)
+	}
+}
+
+func TestSoftInvalid(t *testing.T) {
+	required := cond("keyword", "eq", "or", "a", "x")
+	required.Soft, required.Required = true, true
+	negated := cond("keyword", "neq", "and", "a", "x")
+	negated.Soft = true
+	for _, in := range []Condition{required, negated} {
+		if _, err := New([]Condition{in}).ParseToQuery(); !errors.Is(err, ErrInvalidSoft) {
+			t.Errorf("%s: err = %v, want %v", in.ComparisonOperators, err, ErrInvalidSoft)
+		}
+	}
+}
+
+func TestTermsLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		op   string
+		set  map[string]interface{} // overrides the complete lookup; nil deletes the key
+		want string
+		err  string // the key the error has to name
+	}{
+		{name: "complete", op: "in", want: `{"query":{"bool":{"must":[{"terms":{"user":{"id":"2","index":"users","path":"followers"}}}]}}}`},
+		{name: "nin", op: "nin", want: `{"query":{"bool":{"must_not":[{"terms":{"user":{"id":"2","index":"users","path":"followers"}}}]}}}`},
+		{name: "routing", op: "in", set: map[string]interface{}{"routing": "r1"}, want: `{"query":{"bool":{"must":[{"terms":{"user":{"id":"2","index":"users","path":"followers","routing":"r1"}}}]}}}`},
+		{name: "missing index", op: "in", set: map[string]interface{}{"index": nil}, err: "index"},
+		{name: "missing id", op: "in", set: map[string]interface{}{"id": nil}, err: "id"},
+		{name: "missing path", op: "in", set: map[string]interface{}{"path": nil}, err: "path"},
+		{name: "empty index", op: "in", set: map[string]interface{}{"index": ""}, err: "index"},
+		{name: "empty path", op: "nin", set: map[string]interface{}{"path": ""}, err: "path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := map[string]interface{}{"index": "users", "id": "2", "path": "followers"}
+			for k, v := range tt.set {
+				if v == nil {
+					delete(lookup, k)
+				} else {
+					lookup[k] = v
+				}
+			}
+			e := New([]Condition{cond("array", tt.op, "and", "user", lookup)})
+			if tt.err == "" {
+				if got := queryJSON(t, e); got != tt.want {
+					t.Errorf("got  %s\nwant %s", got, tt.want)
+				}
+				return
+			}
+			_, err := e.ParseToQuery()
+			if !errors.Is(err, ErrIncompleteTermsLookup) || !strings.Contains(err.Error(), `"`+tt.err+`"`) {
+				t.Errorf("err = %v, want %v naming %q", err, ErrIncompleteTermsLookup, tt.err)
+			}
+		})
+	}
+}
+
+func TestInferType(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"bool", true, "boolean"},
+		{"int", 5, "number"},
+		{"uint8", uint8(5), "number"},
+		{"float", 1.5, "number"},
+		{"json number", json.Number("9007199254740993"), "number"},
+		{"string", "hi", "text"},
+		{"numeric string", "42", "text"},
+		{"time", ts, "date"},
+		{"time pointer", &ts, "date"},
+		{"slice", []string{"x"}, "array"},
+		{"array", [2]int{1, 2}, "array"},
+		{"knn", KNN{QueryVector: []float64{1}, K: 1, NumCandidates: 1}, "vector"},
+		{"map", map[string]int{}, "auto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferType(tt.value); got != tt.want {
+				t.Errorf("inferType(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoType(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		in   Condition
+		want string
+	}{
+		{"empty type", cond("", "eq", "and", "a", true), `{"query":{"bool":{"must":[{"term":{"a":true}}]}}}`},
+		{"auto", cond("auto", "gt", "and", "a", 5), `{"query":{"bool":{"must":[{"range":{"a":{"gt":5}}}]}}}`},
+		{"upper case", cond("AUTO", "in", "and", "a", []string{"x"}), `{"query":{"bool":{"must":[{"terms":{"a":["x"]}}]}}}`},
+		{"text", cond("", "like", "and", "a", "hi"), `{"query":{"bool":{"must":[{"match":{"a":"hi"}}]}}}`},
+		{"date", cond("", "gte", "and", "a", ts), `{"query":{"bool":{"must":[{"range":{"a":{"gte":"2024-01-02T03:04:05Z"}}}]}}}`},
+		{"numeric string stays a string", cond("", "eq", "and", "zip", "01234"), `{"query":{"bool":{"must":[{"term":{"zip":"01234"}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, New([]Condition{tt.in})); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAutoTypeUnknown(t *testing.T) {
+	if _, err := New([]Condition{cond("", "eq", "and", "a", map[string]int{})}).ParseToQuery(); err == nil {
+		t.Error("want an error for a value whose type cannot be inferred")
+	}
+}
+
+func TestRelation(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Condition
+		want string
+		err  bool
+	}{
+		{name: "range", in: Condition{Type: "range", ComparisonOperators: "gt", LogicalOperators: "and", Key: "n", Value: 1, Relation: "within"}, want: `{"query":{"bool":{"must":[{"range":{"n":{"gt":1,"relation":"within"}}}]}}}`},
+		{name: "range invalid", in: Condition{Type: "range", ComparisonOperators: "gt", LogicalOperators: "and", Key: "n", Value: 1, Relation: "bogus"}, err: true},
+		{name: "number", in: Condition{Type: "number", ComparisonOperators: "gt", LogicalOperators: "and", Key: "n", Value: 1, Relation: "within"}, err: true},
+		{name: "date", in: Condition{Type: "date", ComparisonOperators: "gt", LogicalOperators: "and", Key: "d", Value: "2024-01-01", Relation: "bogus"}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{tt.in})
+			if tt.err {
+				if _, err := e.ParseToQuery(); !errors.Is(err, ErrInvalidRelation) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidRelation)
+				}
+				return
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package elastic
+
+import "errors"
+
+var (
+	ErrInvalidSlop     = errors.New("slop must be a non-negative integer")
+	ErrInvalidMaxGaps  = errors.New("max_gaps must be greater than or equal to -1")
+	ErrInvalidRelation = errors.New("relation must be one of within, contains, intersects and is only valid on range conditions")
+	ErrInvalidIP       = errors.New("value must be an IP address; eq and neq also take a CIDR block")
+
+	ErrInvalidExpr          = errors.New("invalid expression")
+	ErrIncompatibleOption   = errors.New("condition field does not apply to its operator")
+	ErrInvalidIntervalsRule = errors.New("intervals rule must set exactly one of match, all_of, any_of; any_of takes no max_gaps or ordered")
+
+	ErrInvalidDistanceUnit    = errors.New("distance must be a number followed by one of mm, cm, m, km, in, ft, yd, mi, nmi")
+	ErrInvalidKNN             = errors.New("knn needs a non-empty query vector, a positive k and num_candidates of at least k")
+	ErrKNNInGroup             = errors.New("knn must be a top-level condition")
+	ErrMultipleKNN            = errors.New("at most one knn condition is allowed")
+	ErrKNNQueryWrapper        = errors.New("knn cannot be combined with decay functions or pinned ids")
+	ErrKNNNotQuery            = errors.New("knn is a search section and has no query form")
+	ErrKNNScoringCondition    = errors.New("soft and rank_feature conditions only score and cannot filter a knn search")
+	ErrInvalidPolygon         = errors.New("geo_polygon needs at least three points with lat in [-90, 90] and lon in [-180, 180]")
+	ErrInvalidPrefixLength    = errors.New("prefix_length must be non-negative")
+	ErrInvalidMaxExpansions   = errors.New("max_expansions must be greater than 0")
+	ErrInvalidMatchBoolPrefix = errors.New("match_bool_prefix needs a non-empty string value")
+	ErrMissingLike            = errors.New("more_like_this requires a like value")
+	ErrInvalidRankFeature     = errors.New("rank_feature requires exactly one of saturation, log, sigmoid, linear")
+
+	ErrNeedsNestedWrapper = errors.New("key is under a nested path but the condition has no nested wrapper")
+
+	ErrInvalidChunkSize      = errors.New("terms chunk size must be greater than 0")
+	ErrInvalidMaxClauses     = errors.New("max clauses must be greater than 0")
+	ErrTooManyClauses        = errors.New("query has too many clauses")
+	ErrInvalidMaxIterTerms   = errors.New("max iterator terms must be greater than 0")
+	ErrTooManyIterTerms      = errors.New("iterator yielded too many terms")
+	ErrInvalidQueryCacheSize = errors.New("query cache size must be greater than 0")
+	ErrInvalidSection        = errors.New("section must be one of must, should, must_not, filter")
+
+	ErrInvalidDefaultLogical = errors.New("default logical operator must be one of and, or")
+	ErrInvalidAutoNamePrefix = errors.New("auto name prefix must be non-empty")
+	ErrInvalidAnalyzer       = errors.New("analyzer name must be non-empty")
+	ErrInvalidFormat         = errors.New("format must be one of compact, indented, sorted")
+
+	ErrNegativeBoost         = errors.New("boost must be non-negative")
+	ErrInvalidBoostTotal     = errors.New("group boost total must be non-negative")
+	ErrInvalidSoft           = errors.New("soft conditions cannot be required or negated")
+	ErrInvalidConstantScore  = errors.New("constant score must be non-negative and not on a negated condition")
+	ErrZeroBoost             = errors.New("boost of 0 removes the clause from scoring")
+	ErrPatternOnText         = errors.New("wildcard and regexp on a text field match single tokens; use a keyword field")
+	ErrInvalidAutoSynonyms   = errors.New("auto synonyms apply to like, nlike and combined_fields only")
+	ErrInvalidLenient        = errors.New("lenient applies to like and nlike only")
+	ErrInvalidZeroTerms      = errors.New("zero terms query must be none or all")
+	ErrIncompleteTermsLookup = errors.New("terms lookup needs index, id and path")
+	ErrMixedTermsTypes       = errors.New("terms values mix element types")
+	ErrInvalidPatternValue   = errors.New("starts_with, ends_with and contains need a non-empty string value")
+
+	ErrConflictingConditions     = errors.New("and conditions require different values for the same key and never match")
+	ErrUnsatisfiableRange        = errors.New("and range conditions on the same key leave no value and never match")
+	ErrInvalidMinimumShouldMatch = errors.New("minimum_should_match must be an integer, a percentage or n<value conditions")
+	ErrMinimumShouldMatchTooHigh = errors.New("minimum_should_match exceeds the number of should clauses and matches nothing")
+	ErrTrackScoresWithoutSort    = errors.New("track_scores has no effect without a sort")
+
+	ErrEmptyPinnedIDs = errors.New("pinned ids must be non-empty")
+	ErrInvalidDecay   = errors.New("decay needs a gauss, linear or exp function, a field and a scale")
+
+	ErrFieldNotAllowed        = errors.New("field is not allowed")
+	ErrInvalidConditionStream = errors.New("invalid condition stream")
+	ErrNotURIRepresentable    = errors.New("query cannot be expressed as a URI q string")
+	ErrInvalidFieldName       = errors.New("field name may only contain letters, digits, dots, underscores and hyphens")
+
+	ErrMSearchLengthMismatch = errors.New("msearch indexes and queries must have the same length")
+	ErrInvalidMinScore       = errors.New("min_score must be non-negative")
+	ErrInvalidSize           = errors.New("size must be non-negative")
+	ErrInvalidFrom           = errors.New("from must be non-negative")
+	ErrInvalidTimeout        = errors.New("timeout must be at least 1ms")
+	ErrMissingProfile        = errors.New("response has no profile section; build the request with WithProfile")
+	ErrInvalidSort           = errors.New("sort needs a field, an order of asc or desc, and a script only for _script")
+	ErrMissingSortScript     = errors.New("_script sort requires a script")
+	ErrInvalidHighlight      = errors.New("highlight needs named fields with non-negative fragment_size and number_of_fragments")
+	ErrSourceConflict        = errors.New("source cannot be set together with source includes or excludes")
+	ErrEmptyFieldName        = errors.New("field name must be non-empty")
+	ErrStoredFieldsNone      = errors.New("stored fields _none_ cannot be combined with field names")
+	ErrEmptyRuntimeMappings  = errors.New("runtime mappings must not be empty when set")
+
+	ErrInvalidAggregation = errors.New("aggregation needs a name and a field")
+	ErrInvalidInterval    = errors.New("interval must be a calendar interval or a fixed interval such as 30m")
+	ErrDuplicateAggName   = errors.New("aggregation names must be unique within a level")
+
+	ErrInvalidSearchType        = errors.New("search_type must be query_then_fetch or dfs_query_then_fetch")
+	ErrInvalidCrossClusterIndex = errors.New("cross-cluster index must be cluster:index with both parts non-empty")
+	ErrInvalidPreference        = errors.New("preference must be non-empty")
+	ErrInvalidRouting           = errors.New("routing values must be non-empty")
+	ErrInvalidIndexName         = errors.New("index name must be lowercase without reserved characters")
+)
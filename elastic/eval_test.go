@@ -0,0 +1,154 @@
+package elastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// generic round-trips v through JSON so built queries and documents compare
+// as plain maps, slices, strings and float64 numbers.
+func generic(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var rs interface{}
+	err = json.Unmarshal(b, &rs)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return rs
+}
+
+// matches evaluates a built query against doc, a flat map of field values.
+// It knows bool, term, terms, range, constant_score and match_all, which is
+// enough to compare the logic of two queries over the same documents.
+func matches(t *testing.T, query interface{}, doc map[string]interface{}) bool {
+	t.Helper()
+	q, ok := generic(t, query).(map[string]interface{})
+	if !ok || len(q) != 1 {
+		t.Fatalf("not a query: %v", query)
+	}
+	d := generic(t, doc).(map[string]interface{})
+	return evalQuery(t, q, d)
+}
+
+func evalQuery(t *testing.T, q map[string]interface{}, doc map[string]interface{}) bool {
+	for name, body := range q {
+		switch name {
+		case "match_all":
+			return true
+		case "constant_score":
+			return evalQuery(t, body.(map[string]interface{})["filter"].(map[string]interface{}), doc)
+		case "bool":
+			return evalBool(t, body.(map[string]interface{}), doc)
+		case "term":
+			for field, v := range body.(map[string]interface{}) {
+				if m, ok := v.(map[string]interface{}); ok {
+					v = m["value"]
+				}
+				return fmt.Sprint(doc[field]) == fmt.Sprint(v)
+			}
+		case "terms":
+			for field, values := range body.(map[string]interface{}) {
+				for _, v := range values.([]interface{}) {
+					if fmt.Sprint(doc[field]) == fmt.Sprint(v) {
+						return true
+					}
+				}
+				return false
+			}
+		case "range":
+			for field, bounds := range body.(map[string]interface{}) {
+				n, ok := doc[field].(float64)
+				if !ok {
+					return false
+				}
+				for op, v := range bounds.(map[string]interface{}) {
+					b := v.(float64)
+					if op == "gt" && !(n > b) || op == "gte" && !(n >= b) || op == "lt" && !(n < b) || op == "lte" && !(n <= b) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+		t.Fatalf("unsupported query %q", name)
+	}
+	return false
+}
+
+func evalBool(t *testing.T, b map[string]interface{}, doc map[string]interface{}) bool {
+	clauses := func(section string) []interface{} {
+		rs, _ := b[section].([]interface{})
+		return rs
+	}
+	for _, section := range []string{"must", "filter"} {
+		for _, c := range clauses(section) {
+			if !evalQuery(t, c.(map[string]interface{}), doc) {
+				return false
+			}
+		}
+	}
+	for _, c := range clauses("must_not") {
+		if evalQuery(t, c.(map[string]interface{}), doc) {
+			return false
+		}
+	}
+	should := clauses("should")
+	if len(should) == 0 {
+		return true
+	}
+	// Elasticsearch requires one should clause when there is no must or
+	// filter clause, and none otherwise, unless minimum_should_match is set.
+	required := 0
+	if len(clauses("must")) == 0 && len(clauses("filter")) == 0 {
+		required = 1
+	}
+	if msm, ok := b["minimum_should_match"]; ok {
+		n, err := strconv.Atoi(fmt.Sprint(msm))
+		if err != nil {
+			t.Fatalf("unsupported minimum_should_match %v", msm)
+		}
+		required = n
+	}
+	n := 0
+	for _, c := range should {
+		if evalQuery(t, c.(map[string]interface{}), doc) {
+			n++
+		}
+	}
+	return n >= required
+}
+
+// docs returns every document giving each field one of values.
+func docs(fields []string, values ...interface{}) []map[string]interface{} {
+	rs := []map[string]interface{}{{}}
+	for _, f := range fields {
+		var next []map[string]interface{}
+		for _, d := range rs {
+			for _, v := range values {
+				c := map[string]interface{}{f: v}
+				for k, v := range d {
+					c[k] = v
+				}
+				next = append(next, c)
+			}
+		}
+		rs = next
+	}
+	return rs
+}
+
+// builtQuery returns the query section of e.
+func builtQuery(t *testing.T, e *Elastic) interface{} {
+	t.Helper()
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		t.Fatalf("ParseToQuery: %v", err)
+	}
+	return rs["query"]
+}
@@ -0,0 +1,255 @@
+package elastic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expression syntax of expr conditions, e.g. active AND (age > 18 OR age < 5):
+//
+//	or         = and { OR and }
+//	and        = unary { AND unary }
+//	unary      = NOT unary | "(" or ")" | comparison
+//	comparison = field [ ( = | != | > | >= | < | <= ) value ]
+//
+// A bare field matches true. A value is a number, true, false, a
+// double-quoted string or a bare word. Keywords are case-insensitive.
+
+type exprToken struct {
+	text  string
+	pos   int
+	quote bool // a double-quoted string
+}
+
+type exprParser struct {
+	tokens []exprToken
+	i      int
+	field  func(string) string
+}
+
+var exprRange = map[string]string{">": "gt", ">=": "gte", "<": "lt", "<=": "lte"}
+
+// parseExpr parses s into a query clause, translating fields with field.
+func parseExpr(s string, field func(string) string) (map[string]interface{}, error) {
+	tokens, err := tokenizeExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, field: field}
+	rs, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, p.unexpected()
+	}
+	return rs, nil
+}
+
+func tokenizeExpr(s string) (rs []exprToken, err error) {
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += width
+		case strings.ContainsRune("()", r):
+			rs = append(rs, exprToken{text: s[i : i+1], pos: i})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if r != '=' && j < len(s) && s[j] == '=' {
+				j++
+			}
+			if s[i:j] == "!" {
+				return nil, fmt.Errorf("%w: unexpected %q at offset %d", ErrInvalidExpr, "!", i)
+			}
+			rs = append(rs, exprToken{text: s[i:j], pos: i})
+			i = j
+		case r == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated string at offset %d", ErrInvalidExpr, i)
+			}
+			text, err := strconv.Unquote(s[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad string at offset %d", ErrInvalidExpr, i)
+			}
+			rs = append(rs, exprToken{text: text, pos: i, quote: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) {
+				r, width := utf8.DecodeRuneInString(s[j:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()=!<>"`, r) {
+					break
+				}
+				j += width
+			}
+			rs = append(rs, exprToken{text: s[i:j], pos: i})
+			i = j
+		}
+	}
+	return
+}
+
+func (p *exprParser) peek() (exprToken, bool) {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i], true
+	}
+	return exprToken{}, false
+}
+
+func (p *exprParser) keyword(k string) bool {
+	t, ok := p.peek()
+	if ok && !t.quote && strings.EqualFold(t.text, k) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) unexpected() error {
+	t, ok := p.peek()
+	if !ok {
+		return fmt.Errorf("%w: unexpected end of expression", ErrInvalidExpr)
+	}
+	return fmt.Errorf("%w: unexpected %q at offset %d", ErrInvalidExpr, t.text, t.pos)
+}
+
+func (p *exprParser) or() (map[string]interface{}, error) {
+	first, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	clauses := []interface{}{first}
+	for p.keyword("OR") {
+		next, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, next)
+	}
+	if len(clauses) == 1 {
+		return first, nil
+	}
+	return map[string]interface{}{"bool": &BoolQuery{Should: clauses}}, nil
+}
+
+func (p *exprParser) and() (map[string]interface{}, error) {
+	first, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	clauses := []interface{}{first}
+	for p.keyword("AND") {
+		next, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, next)
+	}
+	if len(clauses) == 1 {
+		return first, nil
+	}
+	return map[string]interface{}{"bool": &BoolQuery{Must: clauses}}, nil
+}
+
+func (p *exprParser) unary() (map[string]interface{}, error) {
+	if p.keyword("NOT") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"bool": &BoolQuery{MustNot: []interface{}{inner}}}, nil
+	}
+	t, ok := p.peek()
+	if ok && t.text == "(" && !t.quote {
+		p.i++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.text != ")" || t.quote {
+			return nil, p.unexpected()
+		}
+		p.i++
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *exprParser) comparison() (map[string]interface{}, error) {
+	t, ok := p.peek()
+	// Any bare word is a field, such as @timestamp; WithStrictFieldNames checks
+	// the names.
+	if !ok || t.quote || p.isKeyword(t) || strings.ContainsAny(t.text[:1], "()=!<>") {
+		return nil, p.unexpected()
+	}
+	p.i++
+	key := p.field(t.text)
+
+	op, ok := p.peek()
+	if !ok || op.quote || !strings.ContainsAny(op.text[:1], "=!<>") {
+		return exprTerm(key, true), nil
+	}
+	p.i++
+	v, ok := p.peek()
+	if !ok || (!v.quote && (p.isKeyword(v) || strings.ContainsAny(v.text[:1], "()=!<>"))) {
+		return nil, p.unexpected()
+	}
+	p.i++
+	value := exprValue(v)
+	switch op.text {
+	case "=":
+		return exprTerm(key, value), nil
+	case "!=":
+		return map[string]interface{}{"bool": &BoolQuery{MustNot: []interface{}{exprTerm(key, value)}}}, nil
+	}
+	return map[string]interface{}{
+		"range": map[string]interface{}{
+			key: map[string]interface{}{
+				exprRange[op.text]: value,
+			},
+		},
+	}, nil
+}
+
+func (p *exprParser) isKeyword(t exprToken) bool {
+	return !t.quote && (strings.EqualFold(t.text, "AND") || strings.EqualFold(t.text, "OR") || strings.EqualFold(t.text, "NOT"))
+}
+
+func exprTerm(key string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"term": map[string]interface{}{
+			key: value,
+		},
+	}
+}
+
+func exprValue(t exprToken) interface{} {
+	if t.quote {
+		return t.text
+	}
+	switch t.text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return f
+	}
+	return t.text
+}
@@ -0,0 +1,41 @@
+package elastic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExprFieldNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		strict bool
+		want   string
+		err    error
+	}{
+		{name: "plain", expr: "age > 5", want: `{"query":{"bool":{"must":[{"range":{"age":{"gt":5}}}]}}}`},
+		{name: "at sign", expr: "@timestamp > 5", want: `{"query":{"bool":{"must":[{"range":{"@timestamp":{"gt":5}}}]}}}`},
+		{name: "non-ASCII", expr: "thành_phố = x", want: `{"query":{"bool":{"must":[{"term":{"thành_phố":"x"}}]}}}`},
+		{name: "strict plain", expr: "age > 5", strict: true, want: `{"query":{"bool":{"must":[{"range":{"age":{"gt":5}}}]}}}`},
+		{name: "strict at sign", expr: "active AND @timestamp > 5", strict: true, err: ErrInvalidFieldName},
+		{name: "keyword", expr: "AND > 5", err: ErrInvalidExpr},
+		{name: "quoted field", expr: `"age" > 5`, err: ErrInvalidExpr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{{Type: "expr", ComparisonOperators: "expr", LogicalOperators: "and", Value: tt.expr}})
+			if tt.strict {
+				e.WithStrictFieldNames()
+			}
+			if tt.err != nil {
+				if _, err := e.ParseToQuery(); !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
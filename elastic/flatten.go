@@ -0,0 +1,120 @@
+package elastic
+
+// WithFlatten removes bool nesting that does not change what matches or how it
+// scores: single-clause bools are replaced by their clause, and nested bools
+// are merged into the section of the parent that combines the same way. Bools
+// with a minimum_should_match other than the one in effect are kept.
+func (e *Elastic) WithFlatten() *Elastic {
+	e.flatten = true
+	return e
+}
+
+func (e *Elastic) flattenBool(b *BoolQuery) {
+	before, counted := effectiveMSM(b)
+	var must, filter, mustNot, should []interface{}
+	keep := func(section string, clause interface{}) {
+		switch section {
+		case "must":
+			must = append(must, clause)
+		case "filter":
+			filter = append(filter, clause)
+		case "must_not":
+			mustNot = append(mustNot, clause)
+		case "should":
+			should = append(should, clause)
+		}
+	}
+	sections := []struct {
+		name    string
+		clauses []interface{}
+	}{{"must", b.Must}, {"filter", b.Filter}, {"must_not", b.MustNot}, {"should", b.Should}}
+	for _, s := range sections {
+		for _, clause := range s.clauses {
+			child := childBool(clause)
+			if child == nil {
+				keep(s.name, clause)
+				continue
+			}
+			e.flattenBool(child)
+			if child.Name != "" || e.autoNames != "" && e.alternatives[child] {
+				// Kept so WithAutoNames can name the alternatives.
+				keep(s.name, clause)
+				continue
+			}
+			if x, ok := singleClause(child, s.name == "must" || s.name == "should"); ok {
+				keep(s.name, x)
+				continue
+			}
+			switch {
+			case (s.name == "must" || s.name == "filter") && len(child.Should) == 0 && child.MinimumShouldMatch == nil:
+				// AND inside AND; must clauses only score inside must.
+				for _, x := range child.Must {
+					keep(s.name, x)
+				}
+				filter = append(filter, child.Filter...)
+				mustNot = append(mustNot, child.MustNot...)
+			case s.name == "should" && counted && before == 1 && onlyShould(child):
+				// OR inside OR.
+				should = append(should, child.Should...)
+			default:
+				keep(s.name, clause)
+			}
+		}
+	}
+	b.Must, b.Filter, b.MustNot, b.Should = must, filter, mustNot, should
+
+	// Moving clauses out of must can change the implicit minimum_should_match.
+	if b.MinimumShouldMatch == nil && len(b.Should) > 0 && counted {
+		if after, _ := effectiveMSM(b); after != before {
+			b.MinimumShouldMatch = before
+		}
+	}
+	if e.stableOrder {
+		sortClauses(b.Must)
+		sortClauses(b.Filter)
+		sortClauses(b.MustNot)
+		sortClauses(b.Should)
+	}
+}
+
+// childBool returns the bool of a {"bool": ...} clause built by this package.
+func childBool(clause interface{}) *BoolQuery {
+	m, ok := clause.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil
+	}
+	b, _ := m["bool"].(*BoolQuery)
+	return b
+}
+
+// effectiveMSM returns the minimum_should_match Elasticsearch applies to b,
+// false when it is not a plain count.
+func effectiveMSM(b *BoolQuery) (int, bool) {
+	if b.MinimumShouldMatch != nil {
+		return shouldCount(b.MinimumShouldMatch)
+	}
+	if len(b.Must) == 0 && len(b.Filter) == 0 {
+		return 1, true
+	}
+	return 0, true
+}
+
+func onlyShould(b *BoolQuery) bool {
+	n, ok := effectiveMSM(b)
+	return ok && n == 1 && len(b.Must) == 0 && len(b.Filter) == 0 && len(b.MustNot) == 0
+}
+
+// singleClause returns the only clause of b when b matches exactly what that
+// clause matches. A lone filter clause only stands in for b where scores are
+// not computed.
+func singleClause(b *BoolQuery, scoring bool) (interface{}, bool) {
+	switch {
+	case len(b.Must) == 1 && len(b.Filter)+len(b.MustNot)+len(b.Should) == 0 && b.MinimumShouldMatch == nil:
+		return b.Must[0], true
+	case len(b.Should) == 1 && onlyShould(b):
+		return b.Should[0], true
+	case !scoring && len(b.Filter) == 1 && len(b.Must)+len(b.MustNot)+len(b.Should) == 0 && b.MinimumShouldMatch == nil:
+		return b.Filter[0], true
+	}
+	return nil, false
+}
@@ -0,0 +1,83 @@
+package elastic
+
+import "testing"
+
+func TestFlatten(t *testing.T) {
+	eq := func(logical, key string) Condition { return cond("keyword", "eq", logical, key, "x") }
+	neq := func(logical, key string) Condition { return cond("keyword", "neq", logical, key, "x") }
+	tests := []struct {
+		name   string
+		params []Condition
+		groups []Group
+		msm    interface{}
+		want   string // the flattened query
+	}{
+		{
+			name:   "single clause group",
+			groups: []Group{{LogicalOperators: "and", Conditions: []Condition{eq("and", "a")}}},
+			want:   `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`,
+		},
+		{
+			name:   "and inside and",
+			params: []Condition{eq("and", "a")},
+			groups: []Group{{LogicalOperators: "and", Conditions: []Condition{eq("and", "b"), eq("and", "c")}}},
+			want:   `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"b":"x"}},{"term":{"c":"x"}}]}}}`,
+		},
+		{
+			name:   "or inside or",
+			params: []Condition{eq("or", "a")},
+			groups: []Group{{LogicalOperators: "or", Conditions: []Condition{eq("or", "b"), eq("or", "c")}}},
+			want:   `{"query":{"bool":{"should":[{"term":{"a":"x"}},{"term":{"b":"x"}},{"term":{"c":"x"}}]}}}`,
+		},
+		{
+			name:   "different minimum_should_match",
+			params: []Condition{eq("or", "a"), eq("or", "d")},
+			groups: []Group{{LogicalOperators: "or", Conditions: []Condition{eq("or", "b"), eq("or", "c")}}},
+			msm:    2,
+			want:   `{"query":{"bool":{"minimum_should_match":2,"should":[{"term":{"a":"x"}},{"term":{"d":"x"}},{"bool":{"should":[{"term":{"b":"x"}},{"term":{"c":"x"}}]}}]}}}`,
+		},
+		{
+			name: "and inside or",
+			groups: []Group{{
+				LogicalOperators: "or",
+				Conditions:       []Condition{eq("or", "a"), eq("or", "b")},
+				Groups:           []Group{{LogicalOperators: "and", Conditions: []Condition{eq("and", "c"), neq("and", "d")}}},
+			}},
+			want: `{"query":{"bool":{"should":[{"bool":{"minimum_should_match":1,"must":[{"term":{"c":"x"}}],"must_not":[{"term":{"d":"x"}}],"should":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}]}}}`,
+		},
+		{
+			name:   "negated group",
+			params: []Condition{eq("and", "a")},
+			groups: []Group{{Negate: true, LogicalOperators: "and", Conditions: []Condition{eq("and", "b")}}},
+			want:   `{"query":{"bool":{"must":[{"term":{"a":"x"}}],"must_not":[{"term":{"b":"x"}}]}}}`,
+		},
+		{
+			name: "deep nesting",
+			groups: []Group{{LogicalOperators: "and", Groups: []Group{{LogicalOperators: "and", Groups: []Group{{
+				LogicalOperators: "or",
+				Conditions:       []Condition{eq("or", "a"), eq("or", "b")},
+			}}}}}},
+			want: `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.params)
+			e.Groups = tt.groups
+			if tt.msm != nil {
+				e.WithMinimumShouldMatch(tt.msm)
+			}
+			flat := e.Clone().WithFlatten()
+			if got := queryJSON(t, flat); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			nested, flattened := builtQuery(t, e), builtQuery(t, flat)
+			for _, doc := range docs([]string{"a", "b", "c", "d"}, "x", "y") {
+				want := matches(t, nested, doc)
+				if got := matches(t, flattened, doc); got != want {
+					t.Errorf("doc %v: flattened %v, unflattened %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package elastic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Format selects how Build serializes the query.
+type Format string
+
+const (
+	FormatCompact  Format = "compact"
+	FormatIndented Format = "indented"
+	// FormatSorted converts every value to plain maps before marshaling, so
+	// object keys are sorted at every depth and output diffs deterministically.
+	FormatSorted Format = "sorted"
+)
+
+var allowFormat = []string{string(FormatCompact), string(FormatIndented), string(FormatSorted)}
+
+// WithFormat sets the output format of Build; compact is the default.
+func (e *Elastic) WithFormat(f Format) *Elastic {
+	if !contains(allowFormat, string(f)) {
+		e.setOptionErr(ErrInvalidFormat)
+		return e
+	}
+	e.format = f
+	return e
+}
+
+// Build returns the query of ParseToQuery serialized in the configured format.
+func (e *Elastic) Build() ([]byte, error) {
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		return nil, err
+	}
+	return e.marshal(rs)
+}
+
+// ParseToValidateBody returns the body for a _validate/query?explain=true dry
+// run. It is the query of Build alone, without search settings, which the
+// validate endpoint does not accept; a knn search fails with ErrKNNNotQuery.
+func (e *Elastic) ParseToValidateBody() ([]byte, error) {
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := rs["knn"]; ok {
+		return nil, ErrKNNNotQuery
+	}
+	return e.marshal(rs)
+}
+
+func (e *Elastic) marshal(v interface{}) ([]byte, error) {
+	switch e.format {
+	case FormatIndented:
+		return json.MarshalIndent(v, "", "  ")
+	case FormatSorted:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var generic interface{}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		err = dec.Decode(&generic)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(generic)
+	}
+	return json.Marshal(v)
+}
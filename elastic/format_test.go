@@ -0,0 +1,95 @@
+package elastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFormats(t *testing.T) {
+	in := []Condition{
+		cond("keyword", "eq", "and", "a", "x"),
+		cond("keyword", "eq", "or", "b", "y"),
+		{Type: "geo", ComparisonOperators: "geo_polygon", LogicalOperators: "and", Key: "loc", Value: []GeoPoint{{1, 2}, {3, 4}, {5, 6}}},
+	}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		// ParseToQuery returns plain maps, so compact output is sorted too.
+		{FormatCompact, `{"query":{"bool":{"filter":[{"geo_polygon":{"loc":{"points":[{"lat":1,"lon":2},{"lat":3,"lon":4},{"lat":5,"lon":6}]}}}],"minimum_should_match":1,"must":[{"term":{"a":"x"}}],"should":[{"term":{"b":"y"}}]}}}`},
+		{FormatSorted, `{"query":{"bool":{"filter":[{"geo_polygon":{"loc":{"points":[{"lat":1,"lon":2},{"lat":3,"lon":4},{"lat":5,"lon":6}]}}}],"minimum_should_match":1,"must":[{"term":{"a":"x"}}],"should":[{"term":{"b":"y"}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			b, err := New(in).WithFormat(tt.format).Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got  %s\nwant %s", b, tt.want)
+			}
+		})
+	}
+
+	indented, err := New(in).WithFormat(FormatIndented).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	if err := json.Indent(&want, []byte(tests[0].want), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if string(indented) != want.String() {
+		t.Errorf("indented got  %s\nwant %s", indented, want.String())
+	}
+}
+
+func TestFormatSortedStructs(t *testing.T) {
+	v := &BoolQuery{
+		Must:               []interface{}{map[string]interface{}{"term": map[string]interface{}{"a": "x"}}},
+		Should:             []interface{}{map[string]interface{}{"term": map[string]interface{}{"b": "y"}}},
+		MinimumShouldMatch: 1,
+	}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatCompact, `{"must":[{"term":{"a":"x"}}],"should":[{"term":{"b":"y"}}],"minimum_should_match":1}`},
+		{FormatSorted, `{"minimum_should_match":1,"must":[{"term":{"a":"x"}}],"should":[{"term":{"b":"y"}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			b, err := New(nil).WithFormat(tt.format).marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got  %s\nwant %s", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDefaultIsCompact(t *testing.T) {
+	in := []Condition{cond("keyword", "eq", "and", "a", "x")}
+	def, err := New(in).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	compact, err := New(in).WithFormat(FormatCompact).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(def) != string(compact) {
+		t.Errorf("default %s, compact %s", def, compact)
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	_, err := New(nil).WithFormat("yaml").Build()
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidFormat)
+	}
+}
@@ -0,0 +1,63 @@
+package elastic
+
+import "encoding/json"
+
+// GeoPoint is one vertex of a geo_polygon condition, whose Value is a
+// []GeoPoint in drawing order. Decoded JSON, a list of {"lat", "lon"}
+// objects, is accepted as well.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+func validatePolygon(value interface{}) error {
+	points, ok := polygonPoints(value)
+	if !ok || len(points) < 3 {
+		return ErrInvalidPolygon
+	}
+	for _, p := range points {
+		if p.Lat < -90 || p.Lat > 90 || p.Lon < -180 || p.Lon > 180 {
+			return ErrInvalidPolygon
+		}
+	}
+	return nil
+}
+
+// polygonPoints returns the vertices of a geo_polygon value.
+func polygonPoints(value interface{}) ([]GeoPoint, bool) {
+	switch v := value.(type) {
+	case []GeoPoint:
+		return v, true
+	case []interface{}:
+		points := make([]GeoPoint, len(v))
+		for i, p := range v {
+			m, ok := p.(map[string]interface{})
+			if !ok || len(m) != 2 {
+				return nil, false
+			}
+			lat, ok := jsonFloat(m["lat"])
+			if !ok {
+				return nil, false
+			}
+			lon, ok := jsonFloat(m["lon"])
+			if !ok {
+				return nil, false
+			}
+			points[i] = GeoPoint{Lat: lat, Lon: lon}
+		}
+		return points, true
+	}
+	return nil, false
+}
+
+// jsonFloat returns a number decoded from JSON, with or without UseNumber.
+func jsonFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,154 @@
+package elastic
+
+import "errors"
+
+// Group is a parenthesised set of conditions and sub-groups, built into its own
+// bool query and attached to the parent by LogicalOperators.
+type Group struct {
+	LogicalOperators string // and, or; empty takes the default logical operator
+	Conditions       []Condition
+	Groups           []Group
+	Negate           bool // wrap the built group in must_not
+	// BoostTotal, when positive, scales the boosts of the should conditions of
+	// the group so they sum to it: each boost b, 1 when unset, becomes
+	// b * BoostTotal / sum(b). Their relative weights are kept.
+	BoostTotal float64
+}
+
+func validateGroups(groups []Group) (err error) {
+	for i := 0; i < len(groups); i++ {
+		g := groups[i]
+		if !contains(allowLogicalOperators, g.LogicalOperators) {
+			return errors.New("unsupported logical operators for group")
+		}
+		if g.BoostTotal < 0 {
+			return ErrInvalidBoostTotal
+		}
+		for _, cond := range g.Conditions {
+			if isKNN(cond) {
+				return ErrKNNInGroup
+			}
+		}
+		err = validate(g.Conditions)
+		if err != nil {
+			return
+		}
+		err = validateGroups(g.Groups)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func toLowerGroups(in []Group, defaultLogical string) (rs []Group) {
+	rs = make([]Group, len(in))
+	for i := 0; i < len(in); i++ {
+		g := in[i]
+		rs[i] = Group{
+			LogicalOperators: logicalOperators(g.LogicalOperators, defaultLogical),
+			Conditions:       toLower(g.Conditions, defaultLogical),
+			Groups:           toLowerGroups(g.Groups, defaultLogical),
+			Negate:           g.Negate,
+			BoostTotal:       g.BoostTotal,
+		}
+	}
+	return
+}
+
+func (e *Elastic) parseGroups(b *BoolQuery, groups []Group) (err error) {
+	for i := 0; i < len(groups); i++ {
+		g := groups[i]
+		clause, err := e.parseGroup(g)
+		if err != nil {
+			return err
+		}
+		err = e.attach(b, g.LogicalOperators, clause)
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
+
+func (e *Elastic) parseGroup(g Group) (rs map[string]interface{}, err error) {
+	b := &BoolQuery{}
+	conditions := g.Conditions
+	if g.BoostTotal > 0 {
+		conditions = normalizeBoosts(conditions, g.BoostTotal)
+	}
+	for i := 0; i < len(conditions); i++ {
+		err = e.parseToDSLQuery(b, conditions[i])
+		if err != nil {
+			return
+		}
+	}
+	err = e.parseGroups(b, g.Groups)
+	if err != nil {
+		return
+	}
+	e.finishBool(b, nil)
+
+	rs = map[string]interface{}{"bool": b}
+	if g.Negate && len(b.Should) > 0 && onlyShould(b) {
+		// NOT (a OR b) is (NOT a) AND (NOT b), which must_not already means.
+		rs = map[string]interface{}{
+			"bool": &BoolQuery{MustNot: b.Should},
+		}
+	} else if g.Negate {
+		rs = map[string]interface{}{
+			"bool": &BoolQuery{MustNot: []interface{}{rs}},
+		}
+	}
+	return
+}
+
+// groupsByKey reports whether WithGroupByKey moves cond into the should bool of
+// its key.
+func groupsByKey(cond Condition) bool {
+	return !cond.Soft && cond.ComparisonOperators != "rank_feature" && sectionFor(cond) == "should"
+}
+
+// attachKeyGroup adds the or alternatives collected for one key to the must of
+// b. A single alternative is added as is unless WithAutoNames has to name it.
+func (e *Elastic) attachKeyGroup(b *BoolQuery, kb *BoolQuery) {
+	if len(kb.Should) == 0 {
+		return
+	}
+	if len(kb.Should) == 1 && e.autoNames == "" {
+		b.Must = append(b.Must, kb.Should[0])
+		return
+	}
+	e.alternatives[kb] = true
+	e.finishBool(kb, nil)
+	b.Must = append(b.Must, map[string]interface{}{"bool": kb})
+}
+
+// normalizeBoosts returns a copy of in with the boosts of its should
+// conditions scaled to sum to total. A zero sum is left unchanged.
+func normalizeBoosts(in []Condition, total float64) []Condition {
+	rs := append([]Condition(nil), in...)
+	var sum float64
+	for _, cond := range rs {
+		if !skipped(cond) && sectionFor(cond) == "should" {
+			sum += boostOf(cond)
+		}
+	}
+	if sum == 0 {
+		return rs
+	}
+	for i, cond := range rs {
+		if !skipped(cond) && sectionFor(cond) == "should" {
+			boost := boostOf(cond) * total / sum
+			rs[i].Boost = &boost
+		}
+	}
+	return rs
+}
+
+func boostOf(cond Condition) float64 {
+	if cond.Boost == nil {
+		return 1
+	}
+	return *cond.Boost
+}
@@ -0,0 +1,274 @@
+package elastic
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNegatedGroup(t *testing.T) {
+	tests := []struct {
+		name    string
+		logical string
+		want    string
+		match   func(a, b bool) bool
+	}{
+		{
+			name:    "NOT (a OR b)",
+			logical: "or",
+			want:    `{"query":{"bool":{"must":[{"bool":{"must_not":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}]}}}`,
+			match:   func(a, b bool) bool { return !(a || b) },
+		},
+		{
+			name:    "NOT (a AND b)",
+			logical: "and",
+			want:    `{"query":{"bool":{"must":[{"bool":{"must_not":[{"bool":{"must":[{"term":{"a":"x"}},{"term":{"b":"x"}}]}}]}}]}}}`,
+			match:   func(a, b bool) bool { return !(a && b) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil)
+			e.Groups = []Group{{
+				Negate: true,
+				Conditions: []Condition{
+					cond("keyword", "eq", tt.logical, "a", "x"),
+					cond("keyword", "eq", tt.logical, "b", "x"),
+				},
+			}}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			query := builtQuery(t, e)
+			for _, doc := range docs([]string{"a", "b"}, "x", "y") {
+				want := tt.match(doc["a"] == "x", doc["b"] == "x")
+				if got := matches(t, query, doc); got != want {
+					t.Errorf("doc %v: got %v, want %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNegatedNestedGroup(t *testing.T) {
+	// NOT (a AND (b OR c))
+	e := New(nil)
+	e.Groups = []Group{{
+		Negate:     true,
+		Conditions: []Condition{cond("keyword", "eq", "and", "a", "x")},
+		Groups: []Group{{
+			LogicalOperators: "and",
+			Conditions: []Condition{
+				cond("keyword", "eq", "or", "b", "x"),
+				cond("keyword", "eq", "or", "c", "x"),
+			},
+		}},
+	}}
+	query := builtQuery(t, e)
+	for _, doc := range docs([]string{"a", "b", "c"}, "x", "y") {
+		want := !(doc["a"] == "x" && (doc["b"] == "x" || doc["c"] == "x"))
+		if got := matches(t, query, doc); got != want {
+			t.Errorf("doc %v: got %v, want %v", doc, got, want)
+		}
+	}
+}
+
+func TestGroupByKey(t *testing.T) {
+	in := []Condition{
+		cond("keyword", "eq", "or", "color", "x"),
+		cond("keyword", "eq", "or", "size", "x"),
+		cond("keyword", "eq", "or", "color", "y"),
+		cond("keyword", "eq", "and", "brand", "x"),
+		cond("keyword", "eq", "or", "size", "y"),
+	}
+	tests := []struct {
+		name  string
+		in    []Condition
+		want  string
+		match func(doc map[string]interface{}) bool
+	}{
+		{
+			name: "two keys and an and condition",
+			in:   in,
+			want: `{"query":{"bool":{"must":[{"term":{"brand":"x"}},{"bool":{"should":[{"term":{"color":"x"}},{"term":{"color":"y"}}]}},{"bool":{"should":[{"term":{"size":"x"}},{"term":{"size":"y"}}]}}]}}}`,
+			match: func(doc map[string]interface{}) bool {
+				return doc["brand"] == "x" && (doc["color"] == "x" || doc["color"] == "y") && (doc["size"] == "x" || doc["size"] == "y")
+			},
+		},
+		{
+			name: "single condition key",
+			in:   in[:3],
+			want: `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"color":"x"}},{"term":{"color":"y"}}]}},{"term":{"size":"x"}}]}}}`,
+			match: func(doc map[string]interface{}) bool {
+				return (doc["color"] == "x" || doc["color"] == "y") && doc["size"] == "x"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.in).WithGroupByKey()
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			query := builtQuery(t, e)
+			for _, doc := range docs([]string{"brand", "color", "size"}, "x", "y", "z") {
+				if got, want := matches(t, query, doc), tt.match(doc); got != want {
+					t.Errorf("doc %v: got %v, want %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGroupByKeyTrace(t *testing.T) {
+	e := New([]Condition{
+		cond("keyword", "eq", "or", "color", "x"),
+		cond("keyword", "eq", "or", "color", "y"),
+		cond("keyword", "eq", "and", "brand", "x"),
+	}).WithGroupByKey()
+	_, trace, err := e.BuildWithTrace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ClauseTrace{
+		{Index: 0, Section: "must", Operator: "eq", Key: "color"},
+		{Index: 1, Section: "must", Operator: "eq", Key: "color"},
+		{Index: 2, Section: "must", Operator: "eq", Key: "brand"},
+	}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("trace = %+v, want %+v", trace, want)
+	}
+}
+
+// shouldBoosts returns the boost of each should clause of the first group of
+// e, 1 when unset.
+func shouldBoosts(t *testing.T, e *Elastic) []float64 {
+	t.Helper()
+	q := generic(t, builtQuery(t, e)).(map[string]interface{})
+	group := q["bool"].(map[string]interface{})["must"].([]interface{})[0].(map[string]interface{})
+	var rs []float64
+	for _, c := range group["bool"].(map[string]interface{})["should"].([]interface{}) {
+		for _, body := range c.(map[string]interface{})["term"].(map[string]interface{}) {
+			boost := 1.0
+			if m, ok := body.(map[string]interface{}); ok {
+				boost = m["boost"].(float64)
+			}
+			rs = append(rs, boost)
+		}
+	}
+	return rs
+}
+
+func TestGroupBoostTotal(t *testing.T) {
+	alt := func(key string, boost float64) Condition {
+		c := cond("keyword", "eq", "or", key, "x")
+		if boost != 0 {
+			c.Boost = floatPtr(boost)
+		}
+		return c
+	}
+	tests := []struct {
+		name  string
+		in    []Condition
+		total float64
+		pre   []float64
+		post  []float64
+	}{
+		{"sum to one", []Condition{alt("a", 1), alt("b", 3)}, 1, []float64{1, 3}, []float64{0.25, 0.75}},
+		{"unset boost counts as one", []Condition{alt("a", 0), alt("b", 3)}, 2, []float64{1, 3}, []float64{0.5, 1.5}},
+		{"and conditions are not scaled", []Condition{alt("a", 2), cond("keyword", "eq", "and", "m", "x"), alt("b", 2)}, 10, []float64{2, 2}, []float64{5, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := func(total float64) *Elastic {
+				e := New(nil)
+				e.Groups = []Group{{LogicalOperators: "and", Conditions: tt.in, BoostTotal: total}}
+				return e
+			}
+			pre, post := shouldBoosts(t, build(0)), shouldBoosts(t, build(tt.total))
+			if !reflect.DeepEqual(pre, tt.pre) || !reflect.DeepEqual(post, tt.post) {
+				t.Fatalf("boosts %v -> %v, want %v -> %v", pre, post, tt.pre, tt.post)
+			}
+			var sum float64
+			for i := range post {
+				sum += post[i]
+				if post[i]/post[0] != pre[i]/pre[0] {
+					t.Errorf("clause %d: relative weight %v, want %v", i, post[i]/post[0], pre[i]/pre[0])
+				}
+			}
+			if sum != tt.total {
+				t.Errorf("boosts sum to %v, want %v", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestGroupBoostTotalLeavesConditions(t *testing.T) {
+	c := cond("keyword", "eq", "or", "a", "x")
+	c.Boost = floatPtr(4)
+	e := New(nil)
+	e.Groups = []Group{{Conditions: []Condition{c, cond("keyword", "eq", "or", "b", "x")}, BoostTotal: 1}}
+	if _, err := e.ParseToQuery(); err != nil {
+		t.Fatal(err)
+	}
+	if got := *e.Groups[0].Conditions[0].Boost; got != 4 {
+		t.Errorf("condition boost changed to %v", got)
+	}
+}
+
+func TestGroupBoostTotalInvalid(t *testing.T) {
+	e := New(nil)
+	e.Groups = []Group{{Conditions: []Condition{cond("keyword", "eq", "or", "a", "x")}, BoostTotal: -1}}
+	if _, err := e.ParseToQuery(); !errors.Is(err, ErrInvalidBoostTotal) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidBoostTotal)
+	}
+}
+
+func TestNegatedOrGroup(t *testing.T) {
+	or := func(key string) Condition { return cond("keyword", "eq", "or", key, "x") }
+	and := func(key string) Condition { return cond("keyword", "eq", "and", key, "x") }
+	tests := []struct {
+		name  string
+		group Group
+		want  string
+		match func(a, b, c, d bool) bool
+	}{
+		{
+			name:  "NOT (a OR b OR c)",
+			group: Group{Negate: true, Conditions: []Condition{or("a"), or("b"), or("c")}},
+			want:  `{"query":{"bool":{"must":[{"bool":{"must_not":[{"term":{"a":"x"}},{"term":{"b":"x"}},{"term":{"c":"x"}}]}}]}}}`,
+			match: func(a, b, c, d bool) bool { return !(a || b || c) },
+		},
+		{
+			name: "NOT (a OR b OR (c AND d))",
+			group: Group{Negate: true, Conditions: []Condition{or("a"), or("b")}, Groups: []Group{{
+				LogicalOperators: "or",
+				Conditions:       []Condition{and("c"), and("d")},
+			}}},
+			want:  `{"query":{"bool":{"must":[{"bool":{"must_not":[{"term":{"a":"x"}},{"term":{"b":"x"}},{"bool":{"must":[{"term":{"c":"x"}},{"term":{"d":"x"}}]}}]}}]}}}`,
+			match: func(a, b, c, d bool) bool { return !(a || b || c && d) },
+		},
+		{
+			name:  "NOT (c AND a) is not rewritten",
+			group: Group{Negate: true, Conditions: []Condition{or("a"), and("c")}},
+			want:  `{"query":{"bool":{"must":[{"bool":{"must_not":[{"bool":{"minimum_should_match":1,"must":[{"term":{"c":"x"}}],"should":[{"term":{"a":"x"}}]}}]}}]}}}`,
+			match: func(a, b, c, d bool) bool { return !(c && a) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil)
+			e.Groups = []Group{tt.group}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+			query := builtQuery(t, e)
+			for _, doc := range docs([]string{"a", "b", "c", "d"}, "x", "y") {
+				want := tt.match(doc["a"] == "x", doc["b"] == "x", doc["c"] == "x", doc["d"] == "x")
+				if got := matches(t, query, doc); got != want {
+					t.Errorf("doc %v: got %v, want %v", doc, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package elastic
+
+// Highlight configures the highlight block of the search body.
+type Highlight struct {
+	PreTags  []string
+	PostTags []string
+	Fields   []HighlightField
+	// Query, when set, is built with the options of the search and used as
+	// highlight_query instead of the main query.
+	Query []Condition
+}
+
+// HighlightField is one highlighted field with its own snippet settings.
+type HighlightField struct {
+	Field             string
+	FragmentSize      *int
+	NumberOfFragments *int
+}
+
+func (h *Highlight) validate() (err error) {
+	if len(h.Fields) == 0 {
+		return ErrInvalidHighlight
+	}
+	for _, f := range h.Fields {
+		if f.Field == "" {
+			return ErrInvalidHighlight
+		}
+		if f.FragmentSize != nil && *f.FragmentSize < 0 {
+			return ErrInvalidHighlight
+		}
+		if f.NumberOfFragments != nil && *f.NumberOfFragments < 0 {
+			return ErrInvalidHighlight
+		}
+	}
+	return
+}
+
+// highlightQuery builds the highlight_query from e.Highlight.Query.
+func (e *Elastic) highlightQuery() (interface{}, error) {
+	c := e.cloneEmpty()
+	c.Params = e.Highlight.Query
+	rs, err := c.ParseToQuery()
+	if err != nil {
+		return nil, err
+	}
+	return rs["query"], nil
+}
+
+func (h *Highlight) toDSL() map[string]interface{} {
+	fields := make(map[string]interface{}, len(h.Fields))
+	for _, f := range h.Fields {
+		opts := map[string]interface{}{}
+		if f.FragmentSize != nil {
+			opts["fragment_size"] = *f.FragmentSize
+		}
+		if f.NumberOfFragments != nil {
+			opts["number_of_fragments"] = *f.NumberOfFragments
+		}
+		fields[f.Field] = opts
+	}
+
+	rs := map[string]interface{}{
+		"fields": fields,
+	}
+	if len(h.PreTags) > 0 {
+		rs["pre_tags"] = h.PreTags
+	}
+	if len(h.PostTags) > 0 {
+		rs["post_tags"] = h.PostTags
+	}
+	return rs
+}
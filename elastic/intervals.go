@@ -0,0 +1,79 @@
+package elastic
+
+// IntervalsRule is a rule of an intervals condition whose Value is a rule tree
+// rather than plain text; the MaxGaps and Ordered of the condition then do not
+// apply. Exactly one of Match, AllOf and AnyOf is set.
+type IntervalsRule struct {
+	Match   string          // text matched as a leaf rule
+	AllOf   []IntervalsRule // every rule must match
+	AnyOf   []IntervalsRule // at least one rule must match
+	MaxGaps *int            // Match and AllOf; -1 means no limit
+	Ordered bool            // Match and AllOf
+}
+
+func (r IntervalsRule) validate() error {
+	n := 0
+	if r.Match != "" {
+		n++
+	}
+	if len(r.AllOf) > 0 {
+		n++
+	}
+	if len(r.AnyOf) > 0 {
+		n++
+	}
+	if n != 1 {
+		return ErrInvalidIntervalsRule
+	}
+	if r.MaxGaps != nil && *r.MaxGaps < -1 {
+		return ErrInvalidMaxGaps
+	}
+	if len(r.AnyOf) > 0 && (r.MaxGaps != nil || r.Ordered) {
+		return ErrInvalidIntervalsRule
+	}
+	for _, sub := range append(append([]IntervalsRule(nil), r.AllOf...), r.AnyOf...) {
+		err := sub.validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r IntervalsRule) toDSL() map[string]interface{} {
+	var name string
+	var body map[string]interface{}
+	switch {
+	case r.Match != "":
+		name, body = "match", map[string]interface{}{
+			"query": r.Match,
+		}
+	case len(r.AllOf) > 0:
+		name, body = "all_of", map[string]interface{}{
+			"intervals": intervalsToDSL(r.AllOf),
+		}
+	default:
+		return map[string]interface{}{
+			"any_of": map[string]interface{}{
+				"intervals": intervalsToDSL(r.AnyOf),
+			},
+		}
+	}
+	if r.MaxGaps != nil {
+		body["max_gaps"] = *r.MaxGaps
+	}
+	if r.Ordered {
+		body["ordered"] = true
+	}
+	return map[string]interface{}{
+		name: body,
+	}
+}
+
+func intervalsToDSL(rules []IntervalsRule) []interface{} {
+	rs := make([]interface{}, len(rules))
+	for i, r := range rules {
+		rs[i] = r.toDSL()
+	}
+	return rs
+}
@@ -0,0 +1,47 @@
+package elastic
+
+import "fmt"
+
+// DefaultMaxIterTerms matches the index.max_terms_count default of
+// Elasticsearch.
+const DefaultMaxIterTerms = 65536
+
+// WithMaxIterTerms sets how many values AddTermsFromIter reads before giving
+// up; DefaultMaxIterTerms when unset.
+func (e *Elastic) WithMaxIterTerms(n int) *Elastic {
+	if n <= 0 {
+		e.setOptionErr(ErrInvalidMaxIterTerms)
+		return e
+	}
+	e.maxIterTerms = n
+	return e
+}
+
+// AddTermsFromIter drains next into the values of an in condition on key.
+// next returns false once there are no more values. It fails without adding
+// the condition when next yields more values than the WithMaxIterTerms limit.
+func (e *Elastic) AddTermsFromIter(key string, logical string, next func() (interface{}, bool)) error {
+	max := e.maxIterTerms
+	if max == 0 {
+		max = DefaultMaxIterTerms
+	}
+	var values []interface{}
+	for {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		if len(values) == max {
+			return fmt.Errorf("%w: more than %d", ErrTooManyIterTerms, max)
+		}
+		values = append(values, v)
+	}
+	e.Params = append(e.Params, Condition{
+		Type:                "array",
+		ComparisonOperators: "in",
+		LogicalOperators:    logical,
+		Key:                 key,
+		Value:               values,
+	})
+	return nil
+}
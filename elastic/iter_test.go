@@ -0,0 +1,70 @@
+package elastic
+
+import (
+	"errors"
+	"testing"
+)
+
+// sliceIter returns an iterator over values and a pointer to the number of
+// values it has handed out.
+func sliceIter(values ...interface{}) (func() (interface{}, bool), *int) {
+	n := 0
+	return func() (interface{}, bool) {
+		if n == len(values) {
+			return nil, false
+		}
+		n++
+		return values[n-1], true
+	}, &n
+}
+
+func TestAddTermsFromIter(t *testing.T) {
+	tests := []struct {
+		name    string
+		logical string
+		values  []interface{}
+		max     int
+		want    string
+		err     error
+		read    int // values taken from the iterator
+	}{
+		{name: "and", logical: "and", values: []interface{}{"a", "b", "c"}, want: `{"query":{"bool":{"must":[{"terms":{"k":["a","b","c"]}}]}}}`, read: 3},
+		{name: "or", logical: "or", values: []interface{}{1, 2}, want: `{"query":{"bool":{"should":[{"terms":{"k":[1,2]}}]}}}`, read: 2},
+		{name: "at the limit", logical: "and", values: []interface{}{"a", "b"}, max: 2, want: `{"query":{"bool":{"must":[{"terms":{"k":["a","b"]}}]}}}`, read: 2},
+		{name: "over the limit", logical: "and", values: []interface{}{"a", "b", "c", "d"}, max: 2, err: ErrTooManyIterTerms, read: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(nil)
+			if tt.max > 0 {
+				e.WithMaxIterTerms(tt.max)
+			}
+			next, read := sliceIter(tt.values...)
+			err := e.AddTermsFromIter("k", tt.logical, next)
+			if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if *read != tt.read {
+				t.Errorf("read %d values, want %d", *read, tt.read)
+			}
+			if err != nil {
+				if len(e.Params) != 0 {
+					t.Errorf("condition added on error: %v", e.Params)
+				}
+				return
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxIterTermsInvalid(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		_, err := New(nil).WithMaxIterTerms(n).ParseToQuery()
+		if !errors.Is(err, ErrInvalidMaxIterTerms) {
+			t.Errorf("%d: err = %v, want %v", n, err, ErrInvalidMaxIterTerms)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package elastic
+
+// KNN is the Value of a vector knn condition, emitted as the top-level knn
+// section of the search rather than as a bool clause. The other conditions
+// become its filter, so Soft and rank_feature conditions, which only score,
+// fail with ErrKNNScoringCondition.
+type KNN struct {
+	QueryVector   []float64
+	K             int
+	NumCandidates int // at least K
+}
+
+func validateKNN(value interface{}) error {
+	knn, ok := value.(KNN)
+	if !ok || len(knn.QueryVector) == 0 || knn.K <= 0 || knn.NumCandidates < knn.K {
+		return ErrInvalidKNN
+	}
+	return nil
+}
+
+func (e *Elastic) knnToDSL(cond Condition) map[string]interface{} {
+	knn := cond.Value.(KNN)
+	rs := map[string]interface{}{
+		"field":          e.mapField(cond.Key),
+		"query_vector":   knn.QueryVector,
+		"k":              knn.K,
+		"num_candidates": knn.NumCandidates,
+	}
+	if cond.Boost != nil {
+		rs["boost"] = *cond.Boost
+	}
+	return rs
+}
+
+func isKNN(cond Condition) bool {
+	return cond.ComparisonOperators == "knn"
+}
+
+// scoringCondition returns the first condition of in or groups that only
+// adjusts scores. As part of a knn filter it would be required to match.
+func scoringCondition(in []Condition, groups []Group) (Condition, bool) {
+	for _, cond := range in {
+		if !skipped(cond) && (cond.Soft || cond.ComparisonOperators == "rank_feature") {
+			return cond, true
+		}
+	}
+	for _, g := range groups {
+		if cond, ok := scoringCondition(g.Conditions, g.Groups); ok {
+			return cond, true
+		}
+	}
+	return Condition{}, false
+}
+
+func emptyBool(b *BoolQuery) bool {
+	return len(b.Must) == 0 && len(b.Filter) == 0 && len(b.MustNot) == 0 && len(b.Should) == 0
+}
@@ -0,0 +1,41 @@
+package elastic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKNNScoringConditions(t *testing.T) {
+	knn := Condition{Type: "vector", ComparisonOperators: "knn", LogicalOperators: "and", Key: "v", Value: KNN{QueryVector: []float64{1, 2}, K: 3, NumCandidates: 10}}
+	soft := cond("keyword", "eq", "and", "a", "x")
+	soft.Soft = true
+	rankFeature := Condition{Type: "number", ComparisonOperators: "rank_feature", LogicalOperators: "and", Key: "pagerank", Value: map[string]interface{}{"saturation": map[string]interface{}{}}}
+	tests := []struct {
+		name   string
+		params []Condition
+		groups []Group
+		want   string
+		err    error
+	}{
+		{name: "filter", params: []Condition{knn, cond("keyword", "eq", "and", "a", "x")}, want: `{"knn":{"field":"v","filter":{"bool":{"must":[{"term":{"a":"x"}}]}},"k":3,"num_candidates":10,"query_vector":[1,2]}}`},
+		{name: "soft", params: []Condition{knn, soft}, err: ErrKNNScoringCondition},
+		{name: "rank_feature", params: []Condition{knn, rankFeature}, err: ErrKNNScoringCondition},
+		{name: "soft in group", params: []Condition{knn}, groups: []Group{{Conditions: []Condition{soft}}}, err: ErrKNNScoringCondition},
+		{name: "rank_feature without knn", params: []Condition{rankFeature}, want: `{"query":{"bool":{"should":[{"rank_feature":{"field":"pagerank","saturation":{}}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.params)
+			e.Groups = tt.groups
+			if tt.err != nil {
+				if _, err := e.ParseToQuery(); !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
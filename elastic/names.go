@@ -0,0 +1,87 @@
+package elastic
+
+import "strconv"
+
+// Queries whose options, _name among them, sit in the body of the field.
+var fieldLevelQueries = map[string]string{
+	"term":              "value",
+	"wildcard":          "value",
+	"regexp":            "value",
+	"match":             "query",
+	"match_phrase":      "query",
+	"match_bool_prefix": "query",
+	"range":             "",
+	"intervals":         "",
+}
+
+// WithAutoNames names the or alternatives of the top-level bool prefix_0,
+// prefix_1, ... in their final order, so the matched_queries of a hit tell which
+// alternatives matched. Alternatives that WithGroupByKey or soft conditions
+// move into must bools of their own are named first, in must order, followed
+// by the top-level should clauses, soft ones included.
+func (e *Elastic) WithAutoNames(prefix string) *Elastic {
+	if prefix == "" {
+		e.setOptionErr(ErrInvalidAutoNamePrefix)
+		return e
+	}
+	e.autoNames = prefix
+	return e
+}
+
+func (e *Elastic) nameShould(b *BoolQuery) {
+	n := 0
+	name := func(clauses []interface{}) {
+		for i, clause := range clauses {
+			clauses[i] = withName(clause, e.autoNames+"_"+strconv.Itoa(n))
+			n++
+		}
+	}
+	for _, clause := range b.Must {
+		if child := childBool(clause); child != nil && e.alternatives[child] {
+			name(child.Should)
+		}
+	}
+	name(b.Should)
+}
+
+// withName returns clause with _name set, expanding the short field form to
+// an object. clause itself is not modified, as raw clauses belong to the caller.
+func withName(clause interface{}, name string) interface{} {
+	m, ok := clause.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return clause
+	}
+	for op, body := range m {
+		if b, ok := body.(*BoolQuery); ok {
+			named := *b
+			named.Name = name
+			return map[string]interface{}{op: &named}
+		}
+		fields, ok := body.(map[string]interface{})
+		if !ok {
+			return clause
+		}
+		named := make(map[string]interface{}, len(fields)+1)
+		for k, v := range fields {
+			named[k] = v
+		}
+		if valueKey, ok := fieldLevelQueries[op]; ok && len(fields) == 1 {
+			for field, v := range fields {
+				opts, ok := v.(map[string]interface{})
+				if !ok {
+					opts = map[string]interface{}{valueKey: v}
+				}
+				namedOpts := make(map[string]interface{}, len(opts)+1)
+				for k, v := range opts {
+					namedOpts[k] = v
+				}
+				namedOpts["_name"] = name
+				named[field] = namedOpts
+			}
+		} else {
+			named["_name"] = name
+		}
+		return map[string]interface{}{op: named}
+	}
+	return clause
+}
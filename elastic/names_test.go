@@ -0,0 +1,41 @@
+package elastic
+
+import "testing"
+
+func TestAutoNamesAlternatives(t *testing.T) {
+	soft := cond("keyword", "eq", "and", "s", "x")
+	soft.Soft = true
+	tests := []struct {
+		name string
+		e    *Elastic
+		want string
+	}{
+		{
+			name: "should",
+			e:    New([]Condition{cond("keyword", "eq", "or", "a", "x"), cond("keyword", "eq", "or", "b", "x")}),
+			want: `{"query":{"bool":{"should":[{"term":{"a":{"_name":"q_0","value":"x"}}},{"term":{"b":{"_name":"q_1","value":"x"}}}]}}}`,
+		},
+		{
+			name: "soft",
+			e:    New([]Condition{cond("keyword", "eq", "or", "a", "x"), cond("keyword", "eq", "or", "b", "x"), soft}),
+			want: `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"a":{"_name":"q_0","value":"x"}}},{"term":{"b":{"_name":"q_1","value":"x"}}}]}}],"should":[{"term":{"s":{"_name":"q_2","value":"x"}}}]}}}`,
+		},
+		{
+			name: "soft flattened",
+			e:    New([]Condition{cond("keyword", "eq", "or", "a", "x"), soft}).WithFlatten(),
+			want: `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"a":{"_name":"q_0","value":"x"}}}]}}],"should":[{"term":{"s":{"_name":"q_1","value":"x"}}}]}}}`,
+		},
+		{
+			name: "group by key",
+			e:    New([]Condition{cond("keyword", "eq", "or", "c", "1"), cond("keyword", "eq", "or", "c", "2"), cond("keyword", "eq", "or", "z", "1")}).WithGroupByKey(),
+			want: `{"query":{"bool":{"must":[{"bool":{"should":[{"term":{"c":{"_name":"q_0","value":"1"}}},{"term":{"c":{"_name":"q_1","value":"2"}}}]}},{"bool":{"should":[{"term":{"z":{"_name":"q_2","value":"1"}}}]}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, tt.e.WithAutoNames("q")); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package elastic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithDeduplicate drops clauses that are identical to one already added to the
+// same bool section, keeping the first occurrence in place. Identical should
+// clauses with different boosts keep the highest boost.
+func (e *Elastic) WithDeduplicate() *Elastic {
+	e.deduplicate = true
+	return e
+}
+
+// WithStableOrder sorts the clauses of every bool section by their JSON form so
+// equivalent condition sets serialize identically. Clause order inside a bool
+// section has no effect on matching or scoring, so query semantics are unchanged.
+// Only clauses are reordered; in/nin values always keep the caller's order.
+func (e *Elastic) WithStableOrder() *Elastic {
+	e.stableOrder = true
+	return e
+}
+
+// WithKeywordSuffix appends suffix (e.g. ".keyword") to the key of text eq/neq
+// conditions so the term query targets the keyword sub-field. It assumes every
+// such text field is mapped with a keyword sub-field of that name.
+func (e *Elastic) WithKeywordSuffix(suffix string) *Elastic {
+	e.keywordSuffix = suffix
+	return e
+}
+
+// WithFieldMap translates condition keys, Keys included, to physical field
+// names during the build, e.g. name to profile.fullName. Keys missing from m
+// are used as given. The keyword suffix is applied after the translation.
+func (e *Elastic) WithFieldMap(m map[string]string) *Elastic {
+	e.fieldMap = make(map[string]string, len(m))
+	for k, v := range m {
+		e.fieldMap[k] = v
+	}
+	return e
+}
+
+// WithExplain asks Elasticsearch to return a score explanation for every hit.
+// Meant for debugging relevance only.
+func (e *Elastic) WithExplain() *Elastic {
+	e.explain = true
+	return e
+}
+
+// WithTrackScores keeps computing _score when the hits are sorted by a field.
+// Without a Sort it has no effect and a warning is recorded.
+func (e *Elastic) WithTrackScores() *Elastic {
+	e.trackScores = true
+	return e
+}
+
+// WithProfile asks Elasticsearch to return query execution profiling data.
+// Meant for debugging performance only.
+func (e *Elastic) WithProfile() *Elastic {
+	e.profile = true
+	return e
+}
+
+// WithTermsChunkSize splits in/nin value lists longer than n into several terms
+// clauses of at most n values, OR'd together in a nested bool. This keeps each
+// clause under the terms count limit at the cost of a larger, slightly slower query.
+func (e *Elastic) WithTermsChunkSize(n int) *Elastic {
+	if n <= 0 {
+		e.setOptionErr(ErrInvalidChunkSize)
+		return e
+	}
+	e.chunkSize = n
+	return e
+}
+
+// WithMaxClauses makes the build fail with ErrTooManyClauses when more than n
+// clauses are produced. Every clause counts, including groups and the clauses
+// inside them, each chunk of a chunked terms list and each comparison of an
+// expr; a raw clause counts as one.
+func (e *Elastic) WithMaxClauses(n int) *Elastic {
+	if n <= 0 {
+		e.setOptionErr(ErrInvalidMaxClauses)
+		return e
+	}
+	e.maxClauses = n
+	return e
+}
+
+// WithStrict turns build warnings into errors.
+func (e *Elastic) WithStrict() *Elastic {
+	e.strict = true
+	return e
+}
+
+// WithPinnedIDs wraps the query in a pinned query so the given documents rank
+// first, in order, above the organic results.
+func (e *Elastic) WithPinnedIDs(ids ...string) *Elastic {
+	if len(ids) == 0 {
+		e.setOptionErr(ErrEmptyPinnedIDs)
+		return e
+	}
+	for _, id := range ids {
+		if id == "" {
+			e.setOptionErr(ErrEmptyPinnedIDs)
+			return e
+		}
+	}
+	e.pinnedIDs = ids
+	return e
+}
+
+// WithStrictFieldNames rejects keys that are not plain field names (letters,
+// digits, dots, underscores and hyphens), for keys that come from untrusted input.
+// The fields of expr conditions are checked too.
+func (e *Elastic) WithStrictFieldNames() *Elastic {
+	e.strictFieldNames = true
+	return e
+}
+
+// WithDefaultLogical sets the logical operator (and, or) used by conditions and
+// groups that leave LogicalOperators empty.
+func (e *Elastic) WithDefaultLogical(op string) *Elastic {
+	op = strings.ToLower(op)
+	if !contains(allowLogicalOperators, op) {
+		e.setOptionErr(ErrInvalidDefaultLogical)
+		return e
+	}
+	e.defaultLogical = op
+	return e
+}
+
+// WithMinimumShouldMatch sets minimum_should_match on the top-level bool query
+// instead of the default. v is an integer or a string in the Elasticsearch
+// syntax: "3", "-2", "75%", "-25%" or conditional specs such as "2<75%" or
+// "2<-25% 9<-3". Soft conditions never count toward it.
+func (e *Elastic) WithMinimumShouldMatch(v interface{}) *Elastic {
+	switch s := v.(type) {
+	case int, int64:
+	case string:
+		if !validMinimumShouldMatch(s) {
+			e.setOptionErr(fmt.Errorf("%w: %q", ErrInvalidMinimumShouldMatch, s))
+			return e
+		}
+	default:
+		e.setOptionErr(fmt.Errorf("%w: %v", ErrInvalidMinimumShouldMatch, v))
+		return e
+	}
+	e.minimumShouldMatch = v
+	return e
+}
+
+// validMinimumShouldMatch checks s is one plain value or a space-separated
+// list of n<value conditions.
+func validMinimumShouldMatch(s string) bool {
+	parts := strings.Fields(s)
+	if len(parts) == 0 {
+		return false
+	}
+	for _, part := range parts {
+		i := strings.Index(part, "<")
+		if i < 0 {
+			if len(parts) > 1 {
+				return false
+			}
+			return validShouldValue(part)
+		}
+		if n, err := strconv.Atoi(part[:i]); err != nil || n < 0 || part[0] == '+' {
+			return false
+		}
+		if !validShouldValue(part[i+1:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validShouldValue checks an integer or percentage, optionally negative.
+func validShouldValue(s string) bool {
+	s = strings.TrimSuffix(s, "%")
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithSearchAnalyzer sets the analyzer of every like, phrase and
+// match_bool_prefix clause that has no Analyzer of its own.
+func (e *Elastic) WithSearchAnalyzer(name string) *Elastic {
+	if name == "" {
+		e.setOptionErr(ErrInvalidAnalyzer)
+		return e
+	}
+	e.analyzer = name
+	return e
+}
+
+// WithSize sets Size. Zero is kept in the search body, so an
+// aggregation-only request returns no hits.
+func (e *Elastic) WithSize(n int) *Elastic {
+	if n < 0 {
+		e.setOptionErr(ErrInvalidSize)
+		return e
+	}
+	e.Size = &n
+	return e
+}
+
+// WithTimeout bounds the search execution time server-side. d is sent in the
+// largest of m, s or ms that represents it exactly, truncated to milliseconds.
+func (e *Elastic) WithTimeout(d time.Duration) *Elastic {
+	if d < time.Millisecond {
+		e.setOptionErr(ErrInvalidTimeout)
+		return e
+	}
+	switch {
+	case d%time.Minute == 0:
+		e.timeout = strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		e.timeout = strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	default:
+		e.timeout = strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+	return e
+}
+
+// WithLimit sets Size, the SQL LIMIT equivalent.
+func (e *Elastic) WithLimit(n int) *Elastic {
+	return e.WithSize(n)
+}
+
+// WithOffset sets From, the SQL OFFSET equivalent.
+func (e *Elastic) WithOffset(n int) *Elastic {
+	if n < 0 {
+		e.setOptionErr(ErrInvalidFrom)
+		return e
+	}
+	e.From = &n
+	return e
+}
+
+// WithConflictDetection warns when two and conditions require different eq
+// values for the same key, which can never match.
+func (e *Elastic) WithConflictDetection() *Elastic {
+	e.conflictDetection = true
+	return e
+}
+
+func (e *Elastic) setOptionErr(err error) {
+	if e.optionErr == nil {
+		e.optionErr = err
+	}
+}
+
+// WithGroupByKey ORs the or conditions of each key in a bool of their own and
+// ANDs those bools together, so color red or blue and size M or L keeps the
+// colors and sizes apart. and conditions stay at the top level.
+func (e *Elastic) WithGroupByKey() *Elastic {
+	e.groupByKey = true
+	return e
+}
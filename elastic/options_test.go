@@ -0,0 +1,177 @@
+package elastic
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestKeywordSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		in     Condition
+		want   string
+	}{
+		{"text eq", ".keyword", cond("text", "eq", "and", "city", "Hà Nội"), `{"query":{"bool":{"must":[{"term":{"city.keyword":"Hà Nội"}}]}}}`},
+		{"text neq", ".keyword", cond("text", "neq", "and", "city", "x"), `{"query":{"bool":{"must_not":[{"term":{"city.keyword":"x"}}]}}}`},
+		{"custom suffix", ".raw", cond("text", "eq", "and", "city", "x"), `{"query":{"bool":{"must":[{"term":{"city.raw":"x"}}]}}}`},
+		{"text like", ".keyword", cond("text", "like", "and", "city", "x"), `{"query":{"bool":{"must":[{"match":{"city":"x"}}]}}}`},
+		{"keyword eq", ".keyword", cond("keyword", "eq", "and", "city", "x"), `{"query":{"bool":{"must":[{"term":{"city":"x"}}]}}}`},
+		{"off", "", cond("text", "eq", "and", "city", "x"), `{"query":{"bool":{"must":[{"term":{"city":"x"}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{tt.in})
+			if tt.suffix != "" {
+				e.WithKeywordSuffix(tt.suffix)
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultLogical(t *testing.T) {
+	in := []Condition{
+		cond("keyword", "eq", "", "a", "x"),
+		cond("keyword", "eq", "", "b", "y"),
+		cond("keyword", "eq", "and", "c", "z"),
+	}
+	tests := []struct {
+		name string
+		op   string
+		want string
+	}{
+		{"and", "and", `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"b":"y"}},{"term":{"c":"z"}}]}}}`},
+		{"or", "or", `{"query":{"bool":{"minimum_should_match":1,"must":[{"term":{"c":"z"}}],"should":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`},
+		{"upper case", "OR", `{"query":{"bool":{"minimum_should_match":1,"must":[{"term":{"c":"z"}}],"should":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, New(in).WithDefaultLogical(tt.op)); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultLogicalInvalid(t *testing.T) {
+	_, err := New([]Condition{cond("keyword", "eq", "", "a", "x")}).WithDefaultLogical("xor").ParseToQuery()
+	if !errors.Is(err, ErrInvalidDefaultLogical) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidDefaultLogical)
+	}
+}
+
+func TestFieldMap(t *testing.T) {
+	m := map[string]string{"name": "profile.fullName", "city": "address.city"}
+	tests := []struct {
+		name   string
+		in     Condition
+		suffix string
+		want   string
+	}{
+		{name: "mapped", in: cond("keyword", "eq", "and", "name", "x"), want: `{"query":{"bool":{"must":[{"term":{"profile.fullName":"x"}}]}}}`},
+		{name: "unmapped", in: cond("keyword", "eq", "and", "age", "1"), want: `{"query":{"bool":{"must":[{"term":{"age":"1"}}]}}}`},
+		{name: "suffix after mapping", in: cond("text", "eq", "and", "city", "x"), suffix: ".keyword", want: `{"query":{"bool":{"must":[{"term":{"address.city.keyword":"x"}}]}}}`},
+		{
+			name: "keys",
+			in:   Condition{Type: "text", ComparisonOperators: "combined_fields", LogicalOperators: "and", Keys: []string{"name", "bio"}, Value: "x"},
+			want: `{"query":{"bool":{"must":[{"combined_fields":{"fields":["profile.fullName","bio"],"query":"x"}}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{tt.in}).WithFieldMap(m)
+			if tt.suffix != "" {
+				e.WithKeywordSuffix(tt.suffix)
+			}
+			if got := queryJSON(t, e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldMapCopiesMap(t *testing.T) {
+	m := map[string]string{"name": "profile.fullName"}
+	e := New([]Condition{cond("keyword", "eq", "and", "name", "x")}).WithFieldMap(m)
+	m["name"] = "changed"
+	want := `{"query":{"bool":{"must":[{"term":{"profile.fullName":"x"}}]}}}`
+	if got := queryJSON(t, e); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMinimumShouldMatchSyntax(t *testing.T) {
+	tests := []struct {
+		msm  interface{}
+		want interface{} // nil for ErrInvalidMinimumShouldMatch
+	}{
+		{3, float64(3)},
+		{"3", "3"},
+		{"-2", "-2"},
+		{"75%", "75%"},
+		{"-25%", "-25%"},
+		{"2<75%", "2<75%"},
+		{"2<-25% 9<-3", "2<-25% 9<-3"},
+		{"abc", nil},
+		{"", nil},
+		{"75", "75"},
+		{"2<", nil},
+		{1.5, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.msm), func(t *testing.T) {
+			e := New([]Condition{cond("keyword", "eq", "or", "a", "x")}).WithMinimumShouldMatch(tt.msm)
+			rs, err := e.ParseToQuery()
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidMinimumShouldMatch) {
+					t.Errorf("err = %v, want %v", err, ErrInvalidMinimumShouldMatch)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := generic(t, rs["query"]).(map[string]interface{})["bool"].(map[string]interface{})
+			if got := b["minimum_should_match"]; got != tt.want {
+				t.Errorf("minimum_should_match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxClauses(t *testing.T) {
+	expr := Condition{Type: "expr", ComparisonOperators: "expr", LogicalOperators: "and", Value: "a = 1 AND (b = 2 OR c = 3)"}
+	chunked := cond("array", "in", "and", "k", []string{"a", "b", "c", "d"})
+	tests := []struct {
+		name  string
+		e     *Elastic
+		count int // clauses produced
+	}{
+		{"conditions", New([]Condition{cond("keyword", "eq", "and", "a", "x"), cond("keyword", "eq", "and", "b", "x")}), 2},
+		{"chunked terms", New([]Condition{chunked}).WithTermsChunkSize(1), 5},
+		{"unchunked terms", New([]Condition{chunked}), 1},
+		{"expr", New([]Condition{expr}), 5},
+		{"group", func() *Elastic {
+			e := New(nil)
+			e.Groups = []Group{{Conditions: []Condition{cond("keyword", "eq", "and", "a", "x"), cond("keyword", "eq", "and", "b", "x")}}}
+			return e
+		}(), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.e.Clone().WithMaxClauses(tt.count).ParseToQuery(); err != nil {
+				t.Errorf("limit %d: %v", tt.count, err)
+			}
+			if tt.count == 1 {
+				return
+			}
+			if _, err := tt.e.Clone().WithMaxClauses(tt.count - 1).ParseToQuery(); !errors.Is(err, ErrTooManyClauses) {
+				t.Errorf("limit %d: err = %v, want %v", tt.count-1, err, ErrTooManyClauses)
+			}
+		})
+	}
+}
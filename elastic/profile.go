@@ -0,0 +1,84 @@
+package elastic
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProfileSummary holds the per-query timings of a search response. The
+// response only has them when the request was built with WithProfile.
+type ProfileSummary struct {
+	Shards []ShardProfile
+}
+
+// ShardProfile is the query tree timed on one shard.
+type ShardProfile struct {
+	ID          string
+	Queries     []QueryProfile
+	RewriteTime time.Duration
+}
+
+// QueryProfile is the time spent in one Lucene query, including its children.
+type QueryProfile struct {
+	Type        string
+	Description string
+	Time        time.Duration
+	Children    []QueryProfile
+}
+
+type profileResponse struct {
+	Profile *struct {
+		Shards []struct {
+			ID       string `json:"id"`
+			Searches []struct {
+				Query       []profileQuery `json:"query"`
+				RewriteTime int64          `json:"rewrite_time"`
+			} `json:"searches"`
+		} `json:"shards"`
+	} `json:"profile"`
+}
+
+type profileQuery struct {
+	Type        string         `json:"type"`
+	Description string         `json:"description"`
+	TimeInNanos int64          `json:"time_in_nanos"`
+	Children    []profileQuery `json:"children"`
+}
+
+// ParseProfile extracts the profile section of a search response. It returns
+// ErrMissingProfile when the response has none.
+func ParseProfile(response []byte) (rs ProfileSummary, err error) {
+	var r profileResponse
+	err = json.Unmarshal(response, &r)
+	if err != nil {
+		return
+	}
+	if r.Profile == nil {
+		return rs, ErrMissingProfile
+	}
+	for _, shard := range r.Profile.Shards {
+		s := ShardProfile{ID: shard.ID}
+		for _, search := range shard.Searches {
+			s.Queries = append(s.Queries, toQueryProfiles(search.Query)...)
+			s.RewriteTime += time.Duration(search.RewriteTime)
+		}
+		rs.Shards = append(rs.Shards, s)
+	}
+	return
+}
+
+func toQueryProfiles(in []profileQuery) []QueryProfile {
+	if len(in) == 0 {
+		return nil
+	}
+	rs := make([]QueryProfile, len(in))
+	for i, q := range in {
+		rs[i] = QueryProfile{
+			Type:        q.Type,
+			Description: q.Description,
+			Time:        time.Duration(q.TimeInNanos),
+			Children:    toQueryProfiles(q.Children),
+		}
+	}
+	return rs
+}
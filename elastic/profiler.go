@@ -0,0 +1,28 @@
+package elastic
+
+import "time"
+
+// WithProfiler calls fn with the time spent in each build stage: toLower,
+// validate, build (the clause loop) and marshal. Without a profiler no clock
+// is read.
+func (e *Elastic) WithProfiler(fn func(stage string, d time.Duration)) *Elastic {
+	e.profiler = fn
+	return e
+}
+
+func (e *Elastic) startStage() (t time.Time) {
+	if e.profiler != nil {
+		t = time.Now()
+	}
+	return
+}
+
+// report sends the time since *start to the profiler and restarts the clock.
+func (e *Elastic) report(stage string, start *time.Time) {
+	if e.profiler == nil {
+		return
+	}
+	now := time.Now()
+	e.profiler(stage, now.Sub(*start))
+	*start = now
+}
@@ -0,0 +1,152 @@
+package elastic
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// WithQueryCache memoizes the queries built by ParseToQuery in an LRU holding
+// up to size entries, keyed by the conditions, groups, raw clauses and build
+// options. The cache is shared by clones of e and safe for concurrent use;
+// builds that fail or are traced are not cached. A hit also restores e.Query,
+// with nested clauses decoded into generic maps.
+func (e *Elastic) WithQueryCache(size int) *Elastic {
+	if size <= 0 {
+		e.setOptionErr(ErrInvalidQueryCacheSize)
+		return e
+	}
+	e.queryCache = newQueryCache(size)
+	return e
+}
+
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type queryCacheEntry struct {
+	key      [sha256.Size]byte
+	query    []byte
+	state    []byte // e.Query
+	warnings []Warning
+}
+
+func newQueryCache(size int) *queryCache {
+	return &queryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+func (c *queryCache) get(key [sha256.Size]byte) (entry *queryCacheEntry, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*queryCacheEntry), true
+}
+
+func (c *queryCache) put(entry *queryCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[entry.key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+// cachedQuery returns the query of e from its cache, decoded into a new map,
+// and restores e.Query and the warnings of the build.
+func (e *Elastic) cachedQuery(key [sha256.Size]byte) (rs map[string]interface{}, ok bool) {
+	entry, ok := e.queryCache.get(key)
+	if !ok {
+		return
+	}
+	var state Query
+	if decodeNumbers(entry.query, &rs) != nil || decodeNumbers(entry.state, &state) != nil {
+		return nil, false
+	}
+	e.Query = state
+	e.warnings = append([]Warning(nil), entry.warnings...)
+	return rs, true
+}
+
+func (e *Elastic) cacheQuery(key [sha256.Size]byte, rs map[string]interface{}) {
+	query, err := json.Marshal(rs)
+	if err != nil {
+		return
+	}
+	state, err := json.Marshal(e.Query)
+	if err != nil {
+		return
+	}
+	e.queryCache.put(&queryCacheEntry{key: key, query: query, state: state, warnings: append([]Warning(nil), e.warnings...)})
+}
+
+// decodeNumbers unmarshals b into v, keeping numbers as json.Number.
+func decodeNumbers(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// queryCacheKey hashes everything ParseToQuery reads. The Go type of each
+// condition value is included because values that serialize alike, such as a
+// time.Time and its RFC 3339 string, may build differently.
+func (e *Elastic) queryCacheKey() (key [sha256.Size]byte, ok bool) {
+	raw := make([][2]interface{}, len(e.raw))
+	for i, r := range e.raw {
+		raw[i] = [2]interface{}{r.section, r.clause}
+	}
+	b, err := json.Marshal(struct {
+		Params, Groups, Raw, ValueTypes                    interface{}
+		NestedPaths, PinnedIDs                             []string
+		Deduplicate, StableOrder, Strict, StrictFieldNames bool
+		ConflictDetection, GroupByKey, Flatten             bool
+		KeywordSuffix, DefaultLogical, Analyzer, AutoNames string
+		AllowedFields, DeniedFields                        map[string]bool
+		FieldMap                                           map[string]string
+		ChunkSize, MaxClauses                              int
+		MinimumShouldMatch, Decays                         interface{}
+	}{
+		Params: e.Params, Groups: e.Groups, Raw: raw, ValueTypes: valueTypes(nil, e.Params, e.Groups),
+		NestedPaths: e.NestedPaths, PinnedIDs: e.pinnedIDs,
+		Deduplicate: e.deduplicate, StableOrder: e.stableOrder, Strict: e.strict, StrictFieldNames: e.strictFieldNames,
+		ConflictDetection: e.conflictDetection, GroupByKey: e.groupByKey, Flatten: e.flatten,
+		KeywordSuffix: e.keywordSuffix, DefaultLogical: e.defaultLogical, Analyzer: e.analyzer, AutoNames: e.autoNames,
+		AllowedFields: e.allowedFields, DeniedFields: e.deniedFields, FieldMap: e.fieldMap,
+		ChunkSize: e.chunkSize, MaxClauses: e.maxClauses,
+		MinimumShouldMatch: e.minimumShouldMatch, Decays: e.decays,
+	})
+	if err != nil {
+		return key, false
+	}
+	return sha256.Sum256(b), true
+}
+
+func valueTypes(rs []string, in []Condition, groups []Group) []string {
+	for _, cond := range in {
+		rs = append(rs, fmt.Sprintf("%T", cond.Value))
+	}
+	for _, g := range groups {
+		rs = valueTypes(append(rs, "("), g.Conditions, g.Groups)
+		rs = append(rs, ")")
+	}
+	return rs
+}
@@ -0,0 +1,170 @@
+package elastic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestQueryCacheHit(t *testing.T) {
+	e := New([]Condition{cond("keyword", "eq", "and", "a", "x")}).WithQueryCache(4)
+	want := `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := e.queryCache.order.Len(); n != 1 {
+		t.Fatalf("%d entries, want 1", n)
+	}
+	rs["query"] = "changed"
+	for i := 0; i < 2; i++ {
+		if got := queryJSON(t, e); got != want {
+			t.Errorf("hit %d: got %s, want %s", i, got, want)
+		}
+	}
+	if n := e.queryCache.order.Len(); n != 1 {
+		t.Errorf("%d entries after hits, want 1", n)
+	}
+}
+
+func TestQueryCacheKey(t *testing.T) {
+	base := New([]Condition{cond("text", "eq", "and", "a", "x")}).WithQueryCache(8)
+	tests := []struct {
+		name string
+		e    *Elastic
+		want string
+	}{
+		{"base", base, `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+		{"other value", base.WithCondition(cond("text", "eq", "and", "b", "y")), `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"b":"y"}}]}}}`},
+		{"other option", base.Clone().WithKeywordSuffix(".keyword"), `{"query":{"bool":{"must":[{"term":{"a.keyword":"x"}}]}}}`},
+		{"base again", base.Clone(), `{"query":{"bool":{"must":[{"term":{"a":"x"}}]}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryJSON(t, tt.e); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+	if n := base.queryCache.order.Len(); n != 3 {
+		t.Errorf("%d entries shared by the clones, want 3", n)
+	}
+}
+
+func TestQueryCacheEviction(t *testing.T) {
+	base := New(nil).WithQueryCache(2)
+	build := func(value string) *Elastic {
+		e := base.WithCondition(cond("keyword", "eq", "and", "a", value))
+		if _, err := e.ParseToQuery(); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	cached := func(e *Elastic) bool {
+		key, _ := e.queryCacheKey()
+		_, ok := base.queryCache.entries[key]
+		return ok
+	}
+	x, y := build("x"), build("y")
+	build("x") // x is now the most recently used
+	z := build("z")
+	for _, tt := range []struct {
+		name string
+		e    *Elastic
+		want bool
+	}{{"x", x, true}, {"y", y, false}, {"z", z, true}} {
+		if got := cached(tt.e); got != tt.want {
+			t.Errorf("%s cached = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQueryCacheWarnings(t *testing.T) {
+	c := cond("keyword", "eq", "or", "a", "x")
+	c.Boost = floatPtr(0)
+	e := New([]Condition{c}).WithQueryCache(2)
+	for i := 0; i < 2; i++ {
+		if _, err := e.ParseToQuery(); err != nil {
+			t.Fatal(err)
+		}
+		if warnings := e.Warnings(); len(warnings) != 1 || !errors.Is(warnings[0], ErrZeroBoost) {
+			t.Errorf("build %d: warnings = %v, want %v", i, warnings, ErrZeroBoost)
+		}
+	}
+}
+
+func TestQueryCacheSkipsTrace(t *testing.T) {
+	e := New([]Condition{cond("keyword", "eq", "and", "a", "x")}).WithQueryCache(2)
+	_, trace, err := e.BuildWithTrace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != 1 {
+		t.Errorf("trace = %v, want one clause", trace)
+	}
+	if n := e.queryCache.order.Len(); n != 0 {
+		t.Errorf("%d entries after a traced build, want 0", n)
+	}
+}
+
+func TestQueryCacheConcurrent(t *testing.T) {
+	base := New(nil).WithQueryCache(4)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value := fmt.Sprint(i % 8)
+			e := base.WithCondition(cond("keyword", "eq", "and", "a", value))
+			want := `{"query":{"bool":{"must":[{"term":{"a":"` + value + `"}}]}}}`
+			for j := 0; j < 50; j++ {
+				rs, err := e.ParseToQuery()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got, _ := json.Marshal(rs); string(got) != want {
+					t.Errorf("got %s, want %s", got, want)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if n := base.queryCache.order.Len(); n > 4 || n != len(base.queryCache.entries) {
+		t.Errorf("%d entries in the list, %d in the map, want at most 4 of each", n, len(base.queryCache.entries))
+	}
+}
+
+func TestQueryCacheInvalidSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		_, err := New(nil).WithQueryCache(size).ParseToQuery()
+		if !errors.Is(err, ErrInvalidQueryCacheSize) {
+			t.Errorf("%d: err = %v, want %v", size, err, ErrInvalidQueryCacheSize)
+		}
+	}
+}
+
+func TestQueryCacheRestoresQuery(t *testing.T) {
+	base := New(nil).WithQueryCache(2)
+	build := func() string {
+		e := base.WithCondition(cond("keyword", "eq", "or", "a", "x")).WithCondition(cond("number", "gt", "and", "n", 1))
+		if _, err := e.ParseToQuery(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	miss, hit := build(), build()
+	if base.queryCache.order.Len() != 1 {
+		t.Fatalf("%d entries, want 1", base.queryCache.order.Len())
+	}
+	if hit != miss {
+		t.Errorf("after a hit  %s\nafter a miss %s", hit, miss)
+	}
+}
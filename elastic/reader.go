@@ -0,0 +1,41 @@
+package elastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NewFromReader decodes a JSON array of conditions from r one element at a
+// time, validating each as it is read. The first invalid condition stops the
+// decode with an error naming its index. Numbers are decoded as json.Number.
+func NewFromReader(r io.Reader) (*Elastic, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("%w: expected an array of conditions", ErrInvalidConditionStream)
+	}
+
+	var in []Condition
+	for i := 0; dec.More(); i++ {
+		var cond Condition
+		err = dec.Decode(&cond)
+		if err != nil {
+			return nil, fmt.Errorf("condition %d: %w", i, err)
+		}
+		err = validate(toLower([]Condition{cond}, "and"))
+		if err != nil {
+			return nil, fmt.Errorf("condition %d: %w", i, err)
+		}
+		in = append(in, cond)
+	}
+	_, err = dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return New(in), nil
+}
@@ -0,0 +1,155 @@
+package elastic
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var allowSearchType = []string{"", "query_then_fetch", "dfs_query_then_fetch"}
+
+// reservedIndexChars cannot appear in an index name. * is left out: search
+// targets may be patterns such as logs-*, and -logs-old excludes an index.
+var reservedIndexChars = `\/?"<>|,#`
+
+// SearchRequest is a complete _search call: target index, URL parameters and body.
+type SearchRequest struct {
+	Index  string // may hold several comma-separated indexes and cluster:index names
+	Params url.Values
+	Body   map[string]interface{}
+}
+
+// Path returns the request path including the encoded URL parameters. Each
+// index is escaped, so date math names such as <logs-{now/d}> keep their /;
+// * is valid in a path and left as is.
+func (r *SearchRequest) Path() string {
+	path := "/_search"
+	if r.Index != "" {
+		indexes := strings.Split(r.Index, ",")
+		for i, index := range indexes {
+			indexes[i] = strings.ReplaceAll(url.PathEscape(index), "%2A", "*")
+		}
+		path = "/" + strings.Join(indexes, ",") + path
+	}
+	if len(r.Params) > 0 {
+		path += "?" + r.Params.Encode()
+	}
+	return path
+}
+
+func (e *Elastic) ParseToSearchRequest() (rs *SearchRequest, err error) {
+	err = e.validateRequest()
+	if err != nil {
+		return
+	}
+	body, err := e.ParseToSearchBody()
+	if err != nil {
+		return
+	}
+
+	params := url.Values{}
+	if e.SearchType != "" {
+		params.Set("search_type", e.SearchType)
+	}
+	if e.RequestCache != nil {
+		params.Set("request_cache", strconv.FormatBool(*e.RequestCache))
+	}
+	if e.IgnoreUnavailable != nil {
+		params.Set("ignore_unavailable", strconv.FormatBool(*e.IgnoreUnavailable))
+	}
+	if e.AllowNoIndices != nil {
+		params.Set("allow_no_indices", strconv.FormatBool(*e.AllowNoIndices))
+	}
+	if e.preference != "" {
+		params.Set("preference", e.preference)
+	}
+	if len(e.routing) > 0 {
+		params.Set("routing", strings.Join(e.routing, ","))
+	}
+	if e.SourceAsParams && e.Source == nil {
+		delete(body, "_source")
+		if len(e.SourceIncludes) > 0 {
+			params.Set("_source_includes", strings.Join(e.SourceIncludes, ","))
+		}
+		if len(e.SourceExcludes) > 0 {
+			params.Set("_source_excludes", strings.Join(e.SourceExcludes, ","))
+		}
+	}
+	rs = &SearchRequest{Index: e.Index, Params: params, Body: body}
+	return
+}
+
+func (e *Elastic) validateRequest() (err error) {
+	if !contains(allowSearchType, e.SearchType) {
+		return ErrInvalidSearchType
+	}
+	for _, index := range strings.Split(e.Index, ",") {
+		err = validateCrossClusterIndex(index)
+		if err != nil {
+			return
+		}
+		err = validateIndexName(index[strings.Index(index, ":")+1:])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// validateCrossClusterIndex checks the cluster:index form used by cross-cluster
+// search. The cluster alias is kept as given, since aliases may be mixed case.
+func validateCrossClusterIndex(index string) error {
+	i := strings.Index(index, ":")
+	if i < 0 {
+		return nil
+	}
+	cluster, name := index[:i], index[i+1:]
+	if cluster == "" || name == "" || strings.Contains(name, ":") {
+		return fmt.Errorf("%w: %q", ErrInvalidCrossClusterIndex, index)
+	}
+	return nil
+}
+
+// validateIndexName rejects uppercase and reserved characters. Date math names
+// such as <logs-{now/d}> are accepted as given.
+func validateIndexName(name string) error {
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		return nil
+	}
+	for _, r := range name {
+		if unicode.IsUpper(r) || strings.ContainsRune(reservedIndexChars, r) {
+			return fmt.Errorf("%w: %q contains %q", ErrInvalidIndexName, name, r)
+		}
+	}
+	return nil
+}
+
+// WithPreference sets the preference request parameter, such as a session ID
+// or _local, so consecutive pages are served by the same shards.
+func (e *Elastic) WithPreference(p string) *Elastic {
+	if p == "" {
+		e.setOptionErr(ErrInvalidPreference)
+		return e
+	}
+	e.preference = p
+	return e
+}
+
+// WithRouting limits the search to the shards holding documents routed by the
+// given values.
+func (e *Elastic) WithRouting(values ...string) *Elastic {
+	if len(values) == 0 {
+		e.setOptionErr(ErrInvalidRouting)
+		return e
+	}
+	for _, v := range values {
+		if v == "" {
+			e.setOptionErr(ErrInvalidRouting)
+			return e
+		}
+	}
+	e.routing = append([]string(nil), values...)
+	return e
+}
@@ -0,0 +1,71 @@
+package elastic
+
+import "fmt"
+
+// WithFieldAllowlist only lets conditions query the given keys. Conditions on
+// other keys are dropped, or fail the build with ErrFieldNotAllowed in strict
+// mode. Keys are matched exactly, before WithFieldMap translates them.
+func (e *Elastic) WithFieldAllowlist(fields []string) *Elastic {
+	e.allowedFields = fieldSet(fields)
+	return e
+}
+
+// WithFieldDenylist drops conditions on the given keys, or fails the build
+// with ErrFieldNotAllowed in strict mode.
+func (e *Elastic) WithFieldDenylist(fields []string) *Elastic {
+	e.deniedFields = fieldSet(fields)
+	return e
+}
+
+func fieldSet(fields []string) map[string]bool {
+	rs := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		rs[f] = true
+	}
+	return rs
+}
+
+// sanitize marks the conditions of in and groups that use a disallowed field
+// as skipped. in and groups must be the copies made by prepare.
+func (e *Elastic) sanitize(in []Condition, groups []Group) error {
+	for i := 0; i < len(in); i++ {
+		if skipped(in[i]) {
+			continue
+		}
+		for _, key := range conditionFields(in[i]) {
+			if e.allowedFields != nil && !e.allowedFields[key] || e.deniedFields[key] {
+				if e.strict {
+					return fmt.Errorf("%w: %q", ErrFieldNotAllowed, key)
+				}
+				in[i].denied = true
+				break
+			}
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		err := e.sanitize(groups[i].Conditions, groups[i].Groups)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conditionFields returns the keys a validated condition queries, including
+// the fields named inside an expr.
+func conditionFields(cond Condition) (rs []string) {
+	if cond.Key != "" {
+		rs = append(rs, cond.Key)
+	}
+	if cond.Nested != "" {
+		rs = append(rs, cond.Nested)
+	}
+	rs = append(rs, cond.Keys...)
+	if cond.Type == "expr" {
+		parseExpr(cond.Value.(string), func(f string) string {
+			rs = append(rs, f)
+			return f
+		})
+	}
+	return
+}
@@ -0,0 +1,203 @@
+package elastic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// FieldSpec names a field to retrieve, optionally with a format such as a date
+// pattern.
+type FieldSpec struct {
+	Field  string
+	Format string
+}
+
+func fieldSpecsToDSL(specs []FieldSpec) []interface{} {
+	rs := make([]interface{}, len(specs))
+	for i, f := range specs {
+		spec := map[string]interface{}{
+			"field": f.Field,
+		}
+		if f.Format != "" {
+			spec["format"] = f.Format
+		}
+		rs[i] = spec
+	}
+	return rs
+}
+
+func validateFieldSpecs(specs []FieldSpec) error {
+	for _, f := range specs {
+		if f.Field == "" {
+			return ErrEmptyFieldName
+		}
+	}
+	return nil
+}
+
+// ParseToSearchBody builds the full _search request body: the query plus any
+// top-level search options set on e.
+func (e *Elastic) ParseToSearchBody() (rs map[string]interface{}, err error) {
+	err = e.validateSearch()
+	if err != nil {
+		return
+	}
+	rs, err = e.ParseToQuery()
+	if err != nil {
+		return
+	}
+	if e.trackScores && len(e.Sort) == 0 {
+		e.warn(-1, "", ErrTrackScoresWithoutSort)
+		if e.strict {
+			return nil, e.warnings[len(e.warnings)-1]
+		}
+	}
+
+	if e.MinScore != nil {
+		rs["min_score"] = *e.MinScore
+	}
+	if e.Size != nil {
+		rs["size"] = *e.Size
+	}
+	if e.From != nil {
+		rs["from"] = *e.From
+	}
+	if len(e.Sort) > 0 {
+		sort := make([]interface{}, len(e.Sort))
+		for i, s := range e.Sort {
+			sort[i] = s.toDSL()
+		}
+		rs["sort"] = sort
+	}
+	if e.Highlight != nil {
+		highlight := e.Highlight.toDSL()
+		if len(e.Highlight.Query) > 0 {
+			highlight["highlight_query"], err = e.highlightQuery()
+			if err != nil {
+				return
+			}
+		}
+		rs["highlight"] = highlight
+	}
+	if e.Source != nil {
+		rs["_source"] = *e.Source
+	} else if len(e.SourceIncludes) > 0 || len(e.SourceExcludes) > 0 {
+		source := map[string]interface{}{}
+		if len(e.SourceIncludes) > 0 {
+			source["includes"] = e.SourceIncludes
+		}
+		if len(e.SourceExcludes) > 0 {
+			source["excludes"] = e.SourceExcludes
+		}
+		rs["_source"] = source
+	}
+	if len(e.Fields) > 0 {
+		rs["fields"] = fieldSpecsToDSL(e.Fields)
+	}
+	if len(e.DocValueFields) > 0 {
+		rs["docvalue_fields"] = fieldSpecsToDSL(e.DocValueFields)
+	}
+	if len(e.StoredFields) > 0 {
+		rs["stored_fields"] = e.StoredFields
+	}
+	if e.RuntimeMappings != nil {
+		rs["runtime_mappings"] = e.RuntimeMappings
+	}
+	if len(e.Aggs) > 0 {
+		rs["aggs"] = e.Aggs
+	}
+	if e.timeout != "" {
+		rs["timeout"] = e.timeout
+	}
+	if e.trackScores {
+		rs["track_scores"] = true
+	}
+	if e.explain {
+		rs["explain"] = true
+	}
+	if e.profile {
+		rs["profile"] = true
+	}
+	return
+}
+
+func (e *Elastic) validateSearch() (err error) {
+	if e.MinScore != nil && *e.MinScore < 0 {
+		return ErrInvalidMinScore
+	}
+	if e.Size != nil && *e.Size < 0 {
+		return ErrInvalidSize
+	}
+	if e.From != nil && *e.From < 0 {
+		return ErrInvalidFrom
+	}
+	if e.Source != nil && (len(e.SourceIncludes) > 0 || len(e.SourceExcludes) > 0) {
+		return ErrSourceConflict
+	}
+	if e.RuntimeMappings != nil && len(e.RuntimeMappings) == 0 {
+		return ErrEmptyRuntimeMappings
+	}
+	for _, f := range append(append([]string(nil), e.SourceIncludes...), e.SourceExcludes...) {
+		if f == "" {
+			return ErrEmptyFieldName
+		}
+	}
+	err = validateFieldSpecs(e.Fields)
+	if err != nil {
+		return
+	}
+	err = validateFieldSpecs(e.DocValueFields)
+	if err != nil {
+		return
+	}
+	for _, f := range e.StoredFields {
+		if f == "" {
+			return ErrEmptyFieldName
+		}
+		if f == "_none_" && len(e.StoredFields) > 1 {
+			return ErrStoredFieldsNone
+		}
+	}
+	for _, s := range e.Sort {
+		err = s.validate()
+		if err != nil {
+			return
+		}
+	}
+	if e.Highlight != nil {
+		err = e.Highlight.validate()
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// BuildMSearch renders an _msearch NDJSON body, pairing indexes[i] with queries[i].
+func BuildMSearch(indexes []string, queries []*Elastic) ([]byte, error) {
+	if len(indexes) != len(queries) {
+		return nil, ErrMSearchLengthMismatch
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < len(queries); i++ {
+		header := map[string]interface{}{}
+		if indexes[i] != "" {
+			header["index"] = indexes[i]
+		}
+		body, err := queries[i].ParseToSearchBody()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range []interface{}{header, body} {
+			b, err := json.Marshal(line)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,85 @@
+package elastic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func searchBody(t *testing.T, e *Elastic) map[string]interface{} {
+	t.Helper()
+	rs, err := e.ParseToSearchBody()
+	if err != nil {
+		t.Fatalf("ParseToSearchBody: %v", err)
+	}
+	return rs
+}
+
+func TestDebugFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    func(*Elastic)
+		explain bool
+		profile bool
+	}{
+		{"default", func(e *Elastic) {}, false, false},
+		{"explain", func(e *Elastic) { e.WithExplain() }, true, false},
+		{"profile", func(e *Elastic) { e.WithProfile() }, false, true},
+		{"both", func(e *Elastic) { e.WithExplain().WithProfile() }, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{cond("keyword", "eq", "and", "a", "x")})
+			tt.opts(e)
+			rs := searchBody(t, e)
+			for key, want := range map[string]bool{"explain": tt.explain, "profile": tt.profile} {
+				v, ok := rs[key]
+				if ok != want {
+					t.Errorf("%s present = %v, want %v", key, ok, want)
+				}
+				if ok && v != true {
+					t.Errorf("%s = %v, want true", key, v)
+				}
+			}
+		})
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name string
+		e    *Elastic
+		want interface{}
+		set  bool
+	}{
+		{"unset", New(nil), nil, false},
+		{"zero", New(nil).WithSize(0), 0, true},
+		{"ten", New(nil).WithSize(10), 10, true},
+		{"limit", New(nil).WithLimit(0), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := searchBody(t, tt.e)["size"]
+			if ok != tt.set || v != tt.want {
+				t.Errorf("size = %v (present %v), want %v (present %v)", v, ok, tt.want, tt.set)
+			}
+		})
+	}
+}
+
+func TestSizeZeroSerialized(t *testing.T) {
+	rs := searchBody(t, New(nil).WithSize(0))
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"size":0`) {
+		t.Errorf("%s has no \"size\":0", b)
+	}
+}
+
+func TestSizeNegative(t *testing.T) {
+	if _, err := New(nil).WithSize(-1).ParseToSearchBody(); err == nil {
+		t.Error("want an error for a negative size")
+	}
+}
@@ -0,0 +1,59 @@
+package elastic
+
+import "strings"
+
+// ConditionSet is a list of conditions combined with set operations before
+// being passed to New. Two conditions are the same element when their Key,
+// ComparisonOperators and Value match; the first one seen is kept.
+type ConditionSet []Condition
+
+// Union returns the conditions of s followed by those of other not in s.
+func (s ConditionSet) Union(other ConditionSet) ConditionSet {
+	seen := make(map[string]bool, len(s)+len(other))
+	var rs ConditionSet
+	for _, in := range [][]Condition{s, other} {
+		for _, cond := range in {
+			id := conditionID(cond)
+			if !seen[id] {
+				seen[id] = true
+				rs = append(rs, cond)
+			}
+		}
+	}
+	return ConditionSet(cloneConditions(rs))
+}
+
+// Intersect returns the conditions of s that are also in other.
+func (s ConditionSet) Intersect(other ConditionSet) ConditionSet {
+	return s.filter(other.ids(), true)
+}
+
+// Difference returns the conditions of s that are not in other.
+func (s ConditionSet) Difference(other ConditionSet) ConditionSet {
+	return s.filter(other.ids(), false)
+}
+
+func (s ConditionSet) filter(ids map[string]bool, keep bool) ConditionSet {
+	seen := make(map[string]bool, len(s))
+	var rs ConditionSet
+	for _, cond := range s {
+		id := conditionID(cond)
+		if ids[id] == keep && !seen[id] {
+			seen[id] = true
+			rs = append(rs, cond)
+		}
+	}
+	return ConditionSet(cloneConditions(rs))
+}
+
+func (s ConditionSet) ids() map[string]bool {
+	rs := make(map[string]bool, len(s))
+	for _, cond := range s {
+		rs[conditionID(cond)] = true
+	}
+	return rs
+}
+
+func conditionID(cond Condition) string {
+	return cond.Key + "\x00" + strings.ToLower(cond.ComparisonOperators) + "\x00" + canonicalJSON(exactNumbers(cond.Value))
+}
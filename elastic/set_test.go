@@ -0,0 +1,74 @@
+package elastic
+
+import (
+	"reflect"
+	"testing"
+)
+
+// setKeys returns Key=Value for each condition of s.
+func setKeys(s ConditionSet) []string {
+	rs := []string{}
+	for _, c := range s {
+		rs = append(rs, c.Key+"="+c.Value.(string))
+	}
+	return rs
+}
+
+func TestConditionSet(t *testing.T) {
+	eq := func(key, value string) Condition { return cond("keyword", "eq", "and", key, value) }
+	ab := ConditionSet{eq("a", "1"), eq("b", "1")}
+	bc := ConditionSet{eq("b", "1"), eq("c", "1")}
+	de := ConditionSet{eq("d", "1"), eq("e", "1")}
+	tests := []struct {
+		name string
+		got  ConditionSet
+		want []string
+	}{
+		{"union overlapping", ab.Union(bc), []string{"a=1", "b=1", "c=1"}},
+		{"union disjoint", ab.Union(de), []string{"a=1", "b=1", "d=1", "e=1"}},
+		{"union empty", ab.Union(nil), []string{"a=1", "b=1"}},
+		{"union drops duplicates", ConditionSet{eq("a", "1"), eq("a", "1")}.Union(nil), []string{"a=1"}},
+		{"intersect overlapping", ab.Intersect(bc), []string{"b=1"}},
+		{"intersect disjoint", ab.Intersect(de), []string{}},
+		{"difference overlapping", ab.Difference(bc), []string{"a=1"}},
+		{"difference disjoint", ab.Difference(de), []string{"a=1", "b=1"}},
+		{"difference self", ab.Difference(ab), []string{}},
+		{"same key, other value", ab.Intersect(ConditionSet{eq("a", "2")}), []string{}},
+		{"same key, other operator", ab.Intersect(ConditionSet{cond("keyword", "neq", "and", "a", "1")}), []string{}},
+		{"operator case", ab.Intersect(ConditionSet{cond("keyword", "EQ", "and", "a", "1")}), []string{"a=1"}},
+		{"logical operator ignored", ab.Intersect(ConditionSet{cond("keyword", "eq", "or", "a", "1")}), []string{"a=1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := setKeys(tt.got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionSetNumbers(t *testing.T) {
+	a := ConditionSet{cond("number", "eq", "and", "n", 1)}
+	b := ConditionSet{cond("number", "eq", "and", "n", 1.0)}
+	if got := a.Intersect(b); len(got) != 1 {
+		t.Errorf("1 and 1.0 intersect to %v, want one condition", got)
+	}
+}
+
+func TestConditionSetNew(t *testing.T) {
+	ab := ConditionSet{cond("keyword", "eq", "and", "a", "x"), cond("keyword", "eq", "and", "b", "y")}
+	e := New(ab.Union(ConditionSet{cond("keyword", "eq", "and", "c", "z")}))
+	want := `{"query":{"bool":{"must":[{"term":{"a":"x"}},{"term":{"b":"y"}},{"term":{"c":"z"}}]}}}`
+	if got := queryJSON(t, e); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestConditionSetLeavesOperands(t *testing.T) {
+	a := ConditionSet{{Type: "text", ComparisonOperators: "combined_fields", LogicalOperators: "and", Keys: []string{"x", "y"}, Value: "q"}}
+	u := a.Union(nil)
+	u[0].Keys[0] = "changed"
+	if a[0].Keys[0] != "x" {
+		t.Errorf("operand Keys changed to %v", a[0].Keys)
+	}
+}
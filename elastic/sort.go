@@ -0,0 +1,58 @@
+package elastic
+
+var allowSortOrder = []string{"", "asc", "desc"}
+var allowScriptSortType = []string{"", "number", "string"}
+
+// SortClause is one entry of the search sort.
+type SortClause struct {
+	Field  string                 // field name, _score, or _script for a script sort
+	Order  string                 // asc, desc; empty keeps the Elasticsearch default
+	Type   string                 // _script only: number (default) or string
+	Script map[string]interface{} // _script only, e.g. {"source": "...", "params": {...}}
+}
+
+func (s SortClause) validate() error {
+	if s.Field == "" || !contains(allowSortOrder, s.Order) {
+		return ErrInvalidSort
+	}
+	if s.Field != "_script" {
+		if s.Script != nil || s.Type != "" {
+			return ErrInvalidSort
+		}
+		return nil
+	}
+	if len(s.Script) == 0 {
+		return ErrMissingSortScript
+	}
+	if !contains(allowScriptSortType, s.Type) {
+		return ErrInvalidSort
+	}
+	return nil
+}
+
+func (s SortClause) toDSL() interface{} {
+	if s.Field == "_script" {
+		typ := s.Type
+		if typ == "" {
+			typ = "number"
+		}
+		body := map[string]interface{}{
+			"type":   typ,
+			"script": s.Script,
+		}
+		if s.Order != "" {
+			body["order"] = s.Order
+		}
+		return map[string]interface{}{
+			"_script": body,
+		}
+	}
+	if s.Order == "" {
+		return s.Field
+	}
+	return map[string]interface{}{
+		s.Field: map[string]interface{}{
+			"order": s.Order,
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package elastic
+
+import "fmt"
+
+// conditionOptions lists the optional Condition fields and the operators they
+// apply to; any other operator ignores them.
+var conditionOptions = []struct {
+	field string
+	set   func(Condition) bool
+	ops   []string
+}{
+	{"Fuzziness", func(c Condition) bool { return c.Fuzziness != "" }, []string{"like", "nlike"}},
+	{"PrefixLength", func(c Condition) bool { return c.PrefixLength != nil }, []string{"like", "nlike"}},
+	{"MaxExpansions", func(c Condition) bool { return c.MaxExpansions != nil }, []string{"like", "nlike"}},
+	{"Slop", func(c Condition) bool { return c.Slop != nil }, []string{"phrase", "nphrase"}},
+	{"MaxGaps", func(c Condition) bool { return c.MaxGaps != nil }, []string{"intervals"}},
+	{"Ordered", func(c Condition) bool { return c.Ordered }, []string{"intervals"}},
+	{"Distance", func(c Condition) bool { return c.Distance != "" }, []string{"geo_distance"}},
+	{"Operator", func(c Condition) bool { return c.Operator != "" }, []string{"combined_fields"}},
+	{"Keys", func(c Condition) bool { return len(c.Keys) > 0 }, []string{"combined_fields", "more_like_this"}},
+	{"ZeroTerms", func(c Condition) bool { return c.ZeroTerms != "" }, []string{"like", "nlike", "phrase", "nphrase", "combined_fields"}},
+	{"Analyzer", func(c Condition) bool { return c.Analyzer != "" }, []string{"like", "nlike", "phrase", "nphrase", "match_bool_prefix", "nmatch_bool_prefix"}},
+}
+
+// NewStrict is New that validates in up front and also rejects optional fields
+// set on an operator that would ignore them, such as Slop on a term query.
+func NewStrict(in []Condition) (*Elastic, error) {
+	lowered := toLower(in, "and")
+	err := validate(lowered)
+	if err != nil {
+		return nil, err
+	}
+	err = validateOptions(lowered)
+	if err != nil {
+		return nil, err
+	}
+	return New(in), nil
+}
+
+func validateOptions(in []Condition) error {
+	for _, cond := range in {
+		for _, opt := range conditionOptions {
+			if opt.set(cond) && !contains(opt.ops, cond.ComparisonOperators) {
+				return fmt.Errorf("%w: %s with %s", ErrIncompatibleOption, opt.field, cond.ComparisonOperators)
+			}
+		}
+	}
+	return nil
+}
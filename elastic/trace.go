@@ -0,0 +1,25 @@
+package elastic
+
+// ClauseTrace records how one condition of Params was turned into a clause.
+type ClauseTrace struct {
+	Index    int    // position in Params
+	Section  string // must, must_not, should, filter; knn for the knn condition
+	Operator string
+	Key      string // physical field after key rewriting
+}
+
+// BuildWithTrace builds the query like ParseToQuery and also reports which bool
+// section each top-level condition was routed to. Conditions inside groups are
+// not traced.
+func (e *Elastic) BuildWithTrace() (query []byte, trace []ClauseTrace, err error) {
+	trace = []ClauseTrace{}
+	e.trace = &trace
+	defer func() { e.trace = nil }()
+
+	rs, err := e.ParseToQuery()
+	if err != nil {
+		return nil, nil, err
+	}
+	query, err = e.marshal(rs)
+	return
+}
@@ -0,0 +1,191 @@
+package elastic
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var luceneRange = map[string]string{"lt": "<", "lte": "<=", "gt": ">", "gte": ">="}
+
+// ToURIQuery renders the conditions as a Lucene string for the q parameter of the
+// URI search API, e.g. `fullName:dvt AND NOT summary:already`.
+//
+// Only term, match, phrase, terms and number/date range conditions are supported;
+// intervals, combined_fields, nested, fuzzy options, analyzers, zero terms,
+// lenient, auto synonyms, constant scores, soft conditions, range relations, raw
+// clauses, pinned ids, decay functions and a minimum_should_match other than 1
+// return ErrNotURIRepresentable. Since AND/OR cannot
+// express optional clauses, a bool with both and and or conditions is not
+// representable either.
+func (e *Elastic) ToURIQuery() (string, error) {
+	in, groups, err := e.prepare()
+	if err != nil {
+		return "", err
+	}
+	if len(e.raw) > 0 || len(e.pinnedIDs) > 0 || len(e.decays) > 0 {
+		return "", ErrNotURIRepresentable
+	}
+	if e.minimumShouldMatch != nil {
+		if n, ok := shouldCount(e.minimumShouldMatch); !ok || n != 1 {
+			return "", fmt.Errorf("%w: minimum_should_match %v", ErrNotURIRepresentable, e.minimumShouldMatch)
+		}
+	}
+	if e.groupByKey {
+		in, groups = uriKeyGroups(in, groups)
+	}
+	return e.uriBool(in, groups)
+}
+
+// uriKeyGroups moves the or alternatives WithGroupByKey collects per key into
+// and groups of their own, so they render as (a OR b) AND (c).
+func uriKeyGroups(in []Condition, groups []Group) ([]Condition, []Group) {
+	var rest []Condition
+	var keys []Group
+	byKey := make(map[string]int)
+	for _, cond := range in {
+		if skipped(cond) || !groupsByKey(cond) {
+			rest = append(rest, cond)
+			continue
+		}
+		i, ok := byKey[cond.Key]
+		if !ok {
+			i = len(keys)
+			byKey[cond.Key] = i
+			keys = append(keys, Group{LogicalOperators: "and"})
+		}
+		keys[i].Conditions = append(keys[i].Conditions, cond)
+	}
+	return rest, append(keys, groups...)
+}
+
+func (e *Elastic) uriBool(in []Condition, groups []Group) (string, error) {
+	var must, mustNot, should []string
+	for i := 0; i < len(in); i++ {
+		cond := in[i]
+		if skipped(cond) {
+			continue
+		}
+		q, err := e.uriClause(cond)
+		if err != nil {
+			return "", err
+		}
+		switch sectionFor(cond) {
+		case "must_not":
+			mustNot = append(mustNot, "NOT "+q)
+		case "should":
+			should = append(should, q)
+		default:
+			must = append(must, q)
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		g := groups[i]
+		conditions := g.Conditions
+		if g.BoostTotal > 0 {
+			conditions = normalizeBoosts(conditions, g.BoostTotal)
+		}
+		q, err := e.uriBool(conditions, g.Groups)
+		if err != nil {
+			return "", err
+		}
+		q = "(" + q + ")"
+		if g.Negate {
+			q = "NOT " + q
+		}
+		if g.LogicalOperators == "or" {
+			should = append(should, q)
+		} else {
+			must = append(must, q)
+		}
+	}
+
+	if len(should) > 0 {
+		if len(must) > 0 {
+			return "", fmt.Errorf("%w: optional or conditions next to and conditions", ErrNotURIRepresentable)
+		}
+		q := strings.Join(should, " OR ")
+		if len(should) > 1 && len(mustNot) > 0 {
+			q = "(" + q + ")"
+		}
+		must = append(must, q)
+	}
+	return strings.Join(append(must, mustNot...), " AND "), nil
+}
+
+func (e *Elastic) uriClause(in Condition) (string, error) {
+	if in.Nested != "" || in.Relation != "" || in.Fuzziness != "" || in.PrefixLength != nil || in.MaxExpansions != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.Key)
+	}
+	if in.Analyzer != "" || in.ZeroTerms != "" || in.Lenient != nil || in.AutoSynonyms != nil || in.ConstantScore != nil || in.Soft {
+		return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.Key)
+	}
+	if e.analyzer != "" && contains([]string{"like", "nlike", "phrase", "nphrase"}, in.ComparisonOperators) {
+		return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.Key)
+	}
+	key := escapeLucene(e.fieldName(in))
+	var q string
+	switch in.ComparisonOperators {
+	case "eq", "neq":
+		q = key + ":" + luceneTerm(in.Value)
+	case "like", "nlike":
+		q = key + ":(" + escapeLucene(fmt.Sprint(in.Value)) + ")"
+	case "phrase", "nphrase":
+		q = key + ":" + strconv.Quote(fmt.Sprint(in.Value))
+		if in.Slop != nil {
+			q += "~" + strconv.Itoa(*in.Slop)
+		}
+	case "in", "nin":
+		v := reflect.ValueOf(in.Value)
+		if v.Kind() != reflect.Slice || v.Len() == 0 {
+			return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.Key)
+		}
+		values := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values[i] = luceneTerm(v.Index(i).Interface())
+		}
+		q = key + ":(" + strings.Join(values, " OR ") + ")"
+	case "lt", "lte", "gt", "gte":
+		if in.Type == "range" {
+			return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.Key)
+		}
+		q = key + ":" + luceneRange[in.ComparisonOperators] + luceneTerm(in.Value)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrNotURIRepresentable, in.ComparisonOperators)
+	}
+	if in.Boost != nil {
+		q += "^" + strconv.FormatFloat(*in.Boost, 'f', -1, 64)
+	}
+	return q, nil
+}
+
+// luceneTerm renders an exact value, quoting it when it contains whitespace.
+// Times are quoted RFC 3339, as they are serialized in the query DSL.
+func luceneTerm(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return strconv.Quote(t.Format(time.RFC3339Nano))
+	case *time.Time:
+		if t != nil {
+			return strconv.Quote(t.Format(time.RFC3339Nano))
+		}
+	}
+	s := fmt.Sprint(v)
+	if strings.ContainsAny(s, " \t\n") {
+		return strconv.Quote(s)
+	}
+	return escapeLucene(s)
+}
+
+func escapeLucene(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`+-=&|><!(){}[]^"~*?:\/`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package elastic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestURIQueryNotRepresentable(t *testing.T) {
+	soft := cond("number", "gte", "and", "age", 18)
+	soft.Soft = true
+	tests := []struct {
+		name string
+		e    *Elastic
+	}{
+		{"soft", New([]Condition{soft})},
+		{"minimum_should_match", New([]Condition{cond("keyword", "eq", "or", "a", "1"), cond("keyword", "eq", "or", "b", "2")}).WithMinimumShouldMatch(2)},
+		{"minimum_should_match percentage", New([]Condition{cond("keyword", "eq", "or", "a", "1")}).WithMinimumShouldMatch("50%")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.e.ToURIQuery(); !errors.Is(err, ErrNotURIRepresentable) {
+				t.Errorf("err = %v, want %v", err, ErrNotURIRepresentable)
+			}
+		})
+	}
+}
+
+func TestURIQueryGroupByKey(t *testing.T) {
+	e := New([]Condition{
+		cond("keyword", "eq", "or", "color", "red"),
+		cond("keyword", "eq", "or", "color", "blue"),
+		cond("keyword", "eq", "or", "size", "M"),
+		cond("keyword", "eq", "and", "brand", "x"),
+	}).WithGroupByKey()
+	got, err := e.ToURIQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "brand:x AND (color:red OR color:blue) AND (size:M)"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestURIQueryMinimumShouldMatchOne(t *testing.T) {
+	for _, msm := range []interface{}{1, "1"} {
+		e := New([]Condition{cond("keyword", "eq", "or", "a", "1"), cond("keyword", "eq", "or", "b", "2")}).WithMinimumShouldMatch(msm)
+		got, err := e.ToURIQuery()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "a:1 OR b:2"; got != want {
+			t.Errorf("%v: got %s, want %s", msm, got, want)
+		}
+	}
+}
+
+func TestURIQueryGroupBoostTotal(t *testing.T) {
+	a := cond("keyword", "eq", "or", "a", "1")
+	a.Boost = floatPtr(4)
+	e := New(nil)
+	e.Groups = []Group{{Conditions: []Condition{a, cond("keyword", "eq", "or", "b", "2")}, BoostTotal: 1}}
+	got, err := e.ToURIQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(a:1^0.8 OR b:2^0.2)"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,225 @@
+package elastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Warning reports a condition that builds a valid query which is probably not
+// what the caller meant.
+type Warning struct {
+	Index int // position of the condition in its slice, -1 when not tied to one
+	Key   string
+	Err   error
+}
+
+func (w Warning) Error() string {
+	if w.Index < 0 {
+		return w.Err.Error()
+	}
+	return fmt.Sprintf("condition %d (%s): %v", w.Index, w.Key, w.Err)
+}
+
+func (w Warning) Unwrap() error {
+	return w.Err
+}
+
+// Warnings returns the warnings found by the last build.
+func (e *Elastic) Warnings() []Warning {
+	return e.warnings
+}
+
+// collectWarnings records the warnings for in and groups; in strict mode the
+// first one is returned as an error instead.
+func (e *Elastic) collectWarnings(in []Condition, groups []Group) (err error) {
+	e.warnings = nil
+	e.checkConditions(in, groups)
+	if e.strict && len(e.warnings) > 0 {
+		return e.warnings[0]
+	}
+	return
+}
+
+func (e *Elastic) checkConditions(in []Condition, groups []Group) {
+	required := make(map[string]interface{})
+	ranges := make(map[string]*rangeBounds)
+	for i := 0; i < len(in); i++ {
+		cond := in[i]
+		if skipped(cond) {
+			continue
+		}
+		if e.conflictDetection && sectionFor(cond) == "must" && cond.ComparisonOperators == "eq" {
+			if v, ok := required[cond.Key]; ok && canonicalJSON(v) != canonicalJSON(exactNumbers(cond.Value)) {
+				e.warn(i, cond.Key, ErrConflictingConditions)
+			} else if !ok {
+				required[cond.Key] = exactNumbers(cond.Value)
+			}
+		}
+		if b, ok := mergeRange(ranges, cond); ok && !b.warned && b.empty() {
+			b.warned = true
+			e.warn(i, cond.Key, fmt.Errorf("%w: %s %v and %s %v", ErrUnsatisfiableRange, b.loOp, b.loValue, b.hiOp, b.hiValue))
+		}
+		if cond.Boost != nil && *cond.Boost == 0 {
+			e.warn(i, cond.Key, ErrZeroBoost)
+		}
+		if cond.Type == "text" && (cond.ComparisonOperators == "wildcard" || cond.ComparisonOperators == "regexp") {
+			e.warn(i, cond.Key, ErrPatternOnText)
+		}
+		if cond.ComparisonOperators == "in" || cond.ComparisonOperators == "nin" {
+			if mixed := mixedKinds(cond.Value); len(mixed) > 0 {
+				e.warn(i, cond.Key, fmt.Errorf("%w: elements %v", ErrMixedTermsTypes, mixed))
+			}
+		}
+	}
+	for i := 0; i < len(groups); i++ {
+		e.checkConditions(groups[i].Conditions, groups[i].Groups)
+	}
+}
+
+// rangeBounds is the tightest lower and upper bound the and range conditions
+// seen so far put on one key.
+type rangeBounds struct {
+	lo, hi           float64
+	loOp, hiOp       string // empty while the side is unbounded
+	loValue, hiValue interface{}
+	warned           bool
+}
+
+// mergeRange narrows the bounds of the key of cond by cond, if it is a
+// required number or date range condition with a comparable value. Date
+// strings, which may be date math, are not compared.
+func mergeRange(ranges map[string]*rangeBounds, cond Condition) (*rangeBounds, bool) {
+	if cond.Type != "number" && cond.Type != "date" || sectionFor(cond) != "must" {
+		return nil, false
+	}
+	op := cond.ComparisonOperators
+	if op != "lt" && op != "lte" && op != "gt" && op != "gte" {
+		return nil, false
+	}
+	if _, ok := cond.Value.(string); ok && cond.Type == "date" {
+		return nil, false
+	}
+	v, ok := rangeValue(cond.Value)
+	if !ok {
+		return nil, false
+	}
+	key := cond.Nested + "\x00" + cond.Key
+	b := ranges[key]
+	if b == nil {
+		b = &rangeBounds{}
+		ranges[key] = b
+	}
+	if op == "gt" || op == "gte" {
+		if b.loOp == "" || v > b.lo || v == b.lo && op == "gt" {
+			b.lo, b.loOp, b.loValue = v, op, cond.Value
+		}
+	} else if b.hiOp == "" || v < b.hi || v == b.hi && op == "lt" {
+		b.hi, b.hiOp, b.hiValue = v, op, cond.Value
+	}
+	return b, true
+}
+
+// empty reports whether no value lies within both bounds.
+func (b *rangeBounds) empty() bool {
+	if b.loOp == "" || b.hiOp == "" {
+		return false
+	}
+	return b.lo > b.hi || b.lo == b.hi && (b.loOp == "gt" || b.hiOp == "lt")
+}
+
+// rangeValue returns a number, numeric string or time bound as a float64.
+func rangeValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return float64(v.UnixNano()), true
+	case *time.Time:
+		return float64(v.UnixNano()), v != nil
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// checkBool warns about a minimum_should_match set for the n or alternatives
+// of the top-level bool that no document can satisfy.
+func (e *Elastic) checkBool(msm interface{}, n int) (err error) {
+	if count, ok := shouldCount(msm); ok && n > 0 && count > n {
+		e.warn(-1, "", fmt.Errorf("%w: %d > %d", ErrMinimumShouldMatchTooHigh, count, n))
+	}
+	if e.strict && len(e.warnings) > 0 {
+		return e.warnings[0]
+	}
+	return
+}
+
+// shouldCount returns msm as an absolute clause count; percentages and
+// combination expressions are not counts.
+func shouldCount(msm interface{}) (int, bool) {
+	switch v := msm.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case json.Number:
+		n, err := strconv.Atoi(v.String())
+		return n, err == nil
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// mixedKinds returns the indices of the elements of a slice value whose
+// category (number, string, bool or other) differs from that of the first
+// element. Integers, floats and json.Number are all numbers.
+func mixedKinds(value interface{}) (rs []int) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return
+	}
+	first := valueCategory(v.Index(0).Interface())
+	for i := 1; i < v.Len(); i++ {
+		if valueCategory(v.Index(i).Interface()) != first {
+			rs = append(rs, i)
+		}
+	}
+	return
+}
+
+func valueCategory(value interface{}) string {
+	if _, ok := value.(json.Number); ok {
+		return "number"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	}
+	return "other"
+}
+
+func (e *Elastic) warn(index int, key string, err error) {
+	e.warnings = append(e.warnings, Warning{Index: index, Key: key, Err: err})
+}
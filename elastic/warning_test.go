@@ -0,0 +1,122 @@
+package elastic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBoostValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		boost   float64
+		strict  bool
+		err     error
+		warning error
+	}{
+		{name: "positive", boost: 2},
+		{name: "zero", boost: 0, warning: ErrZeroBoost},
+		{name: "zero strict", boost: 0, strict: true, err: ErrZeroBoost},
+		{name: "negative", boost: -1, err: ErrNegativeBoost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cond("keyword", "eq", "or", "a", "x")
+			c.Boost = floatPtr(tt.boost)
+			e := New([]Condition{c})
+			if tt.strict {
+				e.WithStrict()
+			}
+			_, err := e.ParseToQuery()
+			if !errors.Is(err, tt.err) || (tt.err == nil) != (err == nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			warnings := e.Warnings()
+			if tt.warning == nil {
+				if len(warnings) != 0 {
+					t.Errorf("warnings = %v, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !errors.Is(warnings[0], tt.warning) || warnings[0].Index != 0 {
+				t.Errorf("warnings = %v, want %v at index 0", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestPatternOperators(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ, op string
+		strict  bool
+		err     bool
+		warning error
+	}{
+		{name: "keyword wildcard", typ: "keyword", op: "wildcard"},
+		{name: "keyword regexp", typ: "keyword", op: "regexp"},
+		{name: "text wildcard", typ: "text", op: "wildcard", warning: ErrPatternOnText},
+		{name: "text regexp", typ: "text", op: "regexp", warning: ErrPatternOnText},
+		{name: "text wildcard strict", typ: "text", op: "wildcard", strict: true, err: true},
+		{name: "keyword wildcard strict", typ: "keyword", op: "wildcard", strict: true},
+		{name: "number wildcard", typ: "number", op: "wildcard", err: true},
+		{name: "date regexp", typ: "date", op: "regexp", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New([]Condition{cond(tt.typ, tt.op, "and", "code", "ab*")})
+			if tt.strict {
+				e.WithStrict()
+			}
+			_, err := e.ParseToQuery()
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if tt.strict && tt.err && !errors.Is(err, ErrPatternOnText) {
+				t.Errorf("err = %v, want %v", err, ErrPatternOnText)
+			}
+			if err != nil {
+				return
+			}
+			warnings := e.Warnings()
+			if tt.warning == nil && len(warnings) != 0 {
+				t.Errorf("warnings = %v, want none", warnings)
+			}
+			if tt.warning != nil && (len(warnings) != 1 || !errors.Is(warnings[0], tt.warning)) {
+				t.Errorf("warnings = %v, want %v", warnings, tt.warning)
+			}
+		})
+	}
+}
+
+func TestMinimumShouldMatchTooHigh(t *testing.T) {
+	soft := cond("keyword", "eq", "and", "s", "x")
+	soft.Soft = true
+	alternatives := []Condition{cond("keyword", "eq", "or", "a", "x"), cond("keyword", "eq", "or", "b", "x")}
+	tests := []struct {
+		name string
+		in   []Condition
+		msm  interface{}
+		warn bool
+	}{
+		{name: "reachable", in: alternatives, msm: 2},
+		{name: "too high", in: alternatives, msm: 3, warn: true},
+		{name: "too high next to soft", in: append(alternatives[:2:2], soft), msm: 3, warn: true},
+		{name: "soft not counted", in: append(alternatives[:1:1], soft), msm: 2, warn: true},
+		{name: "reachable next to soft", in: append(alternatives[:2:2], soft), msm: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.in).WithMinimumShouldMatch(tt.msm)
+			if _, err := e.ParseToQuery(); err != nil {
+				t.Fatal(err)
+			}
+			warnings := e.Warnings()
+			if got := len(warnings) == 1 && errors.Is(warnings[0], ErrMinimumShouldMatchTooHigh); got != tt.warn || !tt.warn && len(warnings) != 0 {
+				t.Errorf("warnings = %v, want %v: %v", warnings, ErrMinimumShouldMatchTooHigh, tt.warn)
+			}
+		})
+	}
+}